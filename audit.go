@@ -0,0 +1,97 @@
+package pgxrecord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+type auditActorKey struct{}
+
+// ContextWithAuditActor returns a copy of ctx that carries actor. Save uses actor when writing to an audit log table.
+func ContextWithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditActor returns the actor carried by ctx or an empty string.
+func auditActor(ctx context.Context) string {
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+	return actor
+}
+
+// AuditLog writes an entry to t.AuditLogTable for record. The changes are the differences between the attributes of
+// record when it was last read or saved and its current attributes. If action is "delete" then all attributes are
+// recorded as changed to null. It returns an error if t.AuditLogTable is nil.
+func (t *Table) AuditLog(ctx context.Context, db DB, record *Record, action string, actor string) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return fmt.Errorf("pgxrecord.Table (%s): AuditLog: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	after := record.attributes
+	if action == "delete" {
+		after = nil
+	}
+
+	err := t.writeAuditLog(ctx, db, t.PKValues(record), action, actor, t.auditChanges(record.originalAttributes, after))
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): AuditLog: %w", t.quotedQualifiedName, err)
+	}
+
+	return nil
+}
+
+// auditChanges returns the changed columns between before and after. A nil before or after means the record did not
+// exist.
+func (t *Table) auditChanges(before, after []any) map[string]any {
+	changes := make(map[string]any)
+	for i, c := range t.Columns {
+		var b, a any
+		if before != nil {
+			b = before[i]
+		}
+		if after != nil {
+			a = after[i]
+		}
+
+		if before != nil && after != nil && reflect.DeepEqual(b, a) {
+			continue
+		}
+
+		changes[c.Name] = map[string]any{"before": b, "after": a}
+	}
+
+	return changes
+}
+
+// writeAuditLog inserts a row into t.AuditLogTable for the record with primary key pk.
+func (t *Table) writeAuditLog(ctx context.Context, db DB, pk []any, action, actor string, changes map[string]any) error {
+	if t.AuditLogTable == nil {
+		return fmt.Errorf("table has no audit log table")
+	}
+
+	recordPK, err := json.Marshal(pk)
+	if err != nil {
+		return err
+	}
+
+	entry := t.AuditLogTable.NewRecord()
+	err = entry.SetAttributesStrict(map[string]any{
+		"table_name": t.quotedQualifiedName,
+		"record_pk":  string(recordPK),
+		"action":     action,
+		"actor":      actor,
+		"changed_at": time.Now(),
+		"changes":    changes,
+	})
+	if err != nil {
+		return err
+	}
+
+	return entry.Save(ctx, db)
+}
@@ -0,0 +1,280 @@
+package pgxrecord_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableAuditLog(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+create temporary table audit_log (
+	id bigint primary key generated by default as identity,
+	table_name text not null,
+	record_pk text not null,
+	action text not null,
+	actor text not null,
+	changed_at timestamptz not null,
+	changes jsonb not null
+);`)
+		require.NoError(t, err)
+
+		auditLogTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"audit_log"},
+		}
+		err = auditLogTable.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name:          pgx.Identifier{"t"},
+			AuditLogTable: auditLogTable,
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		ctx = pgxrecord.ContextWithAuditActor(ctx, "alice")
+
+		record := table.NewRecord()
+		record.SetAttributes(map[string]any{"name": "John", "age": 42})
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		record.Set("age", 43)
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		err = table.AuditLog(ctx, conn, record, "delete", "bob")
+		require.NoError(t, err)
+
+		type entry struct {
+			TableName string
+			RecordPK  string
+			Action    string
+			Actor     string
+			Changes   map[string]any
+		}
+		rows, _ := conn.Query(ctx, `select table_name, record_pk, action, actor, changes from audit_log order by id`)
+		entries, err := pgx.CollectRows(rows, pgx.RowToStructByPos[entry])
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		require.Equal(t, `"t"`, entries[0].TableName)
+		require.Equal(t, "[1]", entries[0].RecordPK)
+		require.Equal(t, "insert", entries[0].Action)
+		require.Equal(t, "alice", entries[0].Actor)
+		require.Len(t, entries[0].Changes, 3)
+
+		require.Equal(t, "update", entries[1].Action)
+		require.Equal(t, map[string]any{"age": map[string]any{"before": float64(42), "after": float64(43)}}, entries[1].Changes)
+
+		require.Equal(t, "delete", entries[2].Action)
+		require.Equal(t, "bob", entries[2].Actor)
+		require.Len(t, entries[2].Changes, 3)
+	})
+}
+
+func TestTableAuditLogFailureRollsBackSave(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		// audit_log is missing the changes column so writing the audit log entry fails.
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+create temporary table audit_log (
+	id bigint primary key generated by default as identity,
+	table_name text not null,
+	record_pk text not null,
+	action text not null,
+	actor text not null,
+	changed_at timestamptz not null
+);`)
+		require.NoError(t, err)
+
+		auditLogTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"audit_log"},
+		}
+		err = auditLogTable.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name:          pgx.Identifier{"t"},
+			AuditLogTable: auditLogTable,
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+		record.SetAttributes(map[string]any{"name": "John", "age": 42})
+		err = record.Save(ctx, conn)
+		require.Error(t, err)
+
+		var n int64
+		err = conn.QueryRow(ctx, `select count(*) from t`).Scan(&n)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, n)
+
+		require.Nil(t, record.Get("id"))
+		require.ElementsMatch(t, []string{"name", "age"}, record.AssignedAttributeNames())
+
+		table.AuditLogTable = nil
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		err = conn.QueryRow(ctx, `select count(*) from t`).Scan(&n)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+	})
+}
+
+func TestTableAuditLogUpsertOnConstraint(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	email text not null constraint t_email_unique unique,
+	name text not null
+);
+create temporary table audit_log (
+	id bigint primary key generated by default as identity,
+	table_name text not null,
+	record_pk text not null,
+	action text not null,
+	actor text not null,
+	changed_at timestamptz not null,
+	changes jsonb not null
+);`)
+		require.NoError(t, err)
+
+		auditLogTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"audit_log"},
+		}
+		err = auditLogTable.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name:          pgx.Identifier{"t"},
+			AuditLogTable: auditLogTable,
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+		record.SetAttributes(map[string]any{"email": "john@example.com", "name": "John"})
+		err = table.UpsertOnConstraint(ctx, conn, record, "t_email_unique")
+		require.NoError(t, err)
+
+		record = table.NewRecord()
+		record.SetAttributes(map[string]any{"email": "john@example.com", "name": "Johnny"})
+		err = table.UpsertOnConstraint(ctx, conn, record, "t_email_unique")
+		require.NoError(t, err)
+		require.Equal(t, "Johnny", record.Get("name"))
+
+		rows, _ := conn.Query(ctx, `select record_pk || ' ' || action from audit_log order by id`)
+		entries, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		require.NoError(t, err)
+		require.Equal(t, []string{"[1] insert", "[1] update"}, entries)
+	})
+}
+
+func TestTableAuditLogInsertReturning(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null
+);
+create temporary table audit_log (
+	id bigint primary key generated by default as identity,
+	table_name text not null,
+	record_pk text not null,
+	action text not null,
+	actor text not null,
+	changed_at timestamptz not null,
+	changes jsonb not null
+);`)
+		require.NoError(t, err)
+
+		auditLogTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"audit_log"},
+		}
+		err = auditLogTable.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name:          pgx.Identifier{"t"},
+			AuditLogTable: auditLogTable,
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+		record.Set("name", "John")
+		var upperName string
+		_, err = table.InsertReturning(ctx, conn, record, []string{"upper(name)"}, &upperName)
+		require.NoError(t, err)
+		require.Equal(t, "JOHN", upperName)
+
+		rows, _ := conn.Query(ctx, `select record_pk || ' ' || action from audit_log order by id`)
+		entries, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		require.NoError(t, err)
+		require.Equal(t, []string{"[1] insert"}, entries)
+	})
+}
+
+func TestTableAuditLogInsertDefaultValues(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null default 'unknown'
+);
+create temporary table audit_log (
+	id bigint primary key generated by default as identity,
+	table_name text not null,
+	record_pk text not null,
+	action text not null,
+	actor text not null,
+	changed_at timestamptz not null,
+	changes jsonb not null
+);`)
+		require.NoError(t, err)
+
+		auditLogTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"audit_log"},
+		}
+		err = auditLogTable.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name:          pgx.Identifier{"t"},
+			AuditLogTable: auditLogTable,
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record, err := table.InsertDefaultValues(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, "unknown", record.Get("name"))
+
+		rows, _ := conn.Query(ctx, `select record_pk || ' ' || action from audit_log order by id`)
+		entries, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		require.NoError(t, err)
+		require.Equal(t, []string{"[1] insert"}, entries)
+	})
+}
@@ -0,0 +1,121 @@
+package pgxrecord
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Debug returns a DB that logs all queries to os.Stderr before passing them to db. See DebugWriter.
+func Debug(db DB) DB {
+	return DebugWriter(db, os.Stderr)
+}
+
+// DebugWriter returns a DB that logs all queries to w before passing them to db. If db is a pgx.Tx then the returned
+// DB is also a pgx.Tx so savepoints and prepared statements are still used. Otherwise the returned DB has a Begin
+// method that begins a transaction on db, if db supports it, and logs the queries run in it. Other methods of db,
+// such as those of *pgx.Conn, are hidden so statements prepared with PrepareStatements are not used.
+func DebugWriter(db DB, w io.Writer) DB {
+	if tx, ok := db.(pgx.Tx); ok {
+		return &debugTx{Tx: tx, w: w}
+	}
+	return &debugDB{db: db, w: w}
+}
+
+// logQuery writes sql and optionsAndArgs to w.
+func logQuery(w io.Writer, sql string, optionsAndArgs []any) {
+	fmt.Fprintf(w, "%s pgxrecord: %s %v\n", time.Now().Format(time.RFC3339Nano), sql, optionsAndArgs)
+}
+
+type debugDB struct {
+	db DB
+	w  io.Writer
+}
+
+func (d *debugDB) Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error) {
+	logQuery(d.w, sql, optionsAndArgs)
+	return d.db.Query(ctx, sql, optionsAndArgs...)
+}
+
+func (d *debugDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	b, ok := d.db.(beginner)
+	if !ok {
+		return nil, fmt.Errorf("db does not support transactions")
+	}
+
+	tx, err := b.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &debugTx{Tx: tx, w: d.w}, nil
+}
+
+// debugTx is a pgx.Tx that logs queries made with Query.
+type debugTx struct {
+	pgx.Tx
+	w io.Writer
+}
+
+func (d *debugTx) Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error) {
+	logQuery(d.w, sql, optionsAndArgs)
+	return d.Tx.Query(ctx, sql, optionsAndArgs...)
+}
+
+func (d *debugTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := d.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &debugTx{Tx: tx, w: d.w}, nil
+}
+
+// Explain returns the query plan for the SQL that would be used to perform operation on record. operation must be
+// insert, update, select, or delete. select and delete use the primary key of record. The SQL is explained but not
+// executed.
+func (t *Table) Explain(ctx context.Context, db DB, operation string, record *Record) (string, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return "", fmt.Errorf("pgxrecord.Table (%s): Explain: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	var sql string
+	var args []any
+	var err error
+	switch operation {
+	case "insert":
+		sql, args, err = t.InsertSQL(record)
+	case "update":
+		sql, args, err = t.UpdateSQL(record)
+	case "select":
+		if t.noPrimaryKey {
+			err = errNoPrimaryKey
+		} else {
+			sql, args = t.selectByPKQuery, t.PKValues(record)
+		}
+	case "delete":
+		sql, args, err = t.DeleteSQL(record)
+	default:
+		err = fmt.Errorf("unknown operation %q", operation)
+	}
+	if err != nil {
+		return "", fmt.Errorf("pgxrecord.Table (%s): Explain: %w", t.quotedQualifiedName, err)
+	}
+
+	rows, _ := db.Query(ctx, "explain (format text) "+sql, args...)
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", fmt.Errorf("pgxrecord.Table (%s): Explain: %w", t.quotedQualifiedName, err)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
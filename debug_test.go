@@ -0,0 +1,182 @@
+package pgxrecord_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+type errDB struct {
+	err error
+}
+
+func (db *errDB) Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error) {
+	return nil, db.err
+}
+
+func TestDebugWriter(t *testing.T) {
+	t.Parallel()
+
+	queryErr := errors.New("query failed")
+	buf := &bytes.Buffer{}
+	db := pgxrecord.DebugWriter(&errDB{err: queryErr}, buf)
+
+	_, err := db.Query(context.Background(), "select $1::int", 42)
+	require.ErrorIs(t, err, queryErr)
+	require.Contains(t, buf.String(), "select $1::int [42]")
+}
+
+// fakeTx is a pgx.Tx that records queries. Methods other than Query and Begin are not implemented.
+type fakeTx struct {
+	pgx.Tx
+	queries *[]string
+}
+
+func (tx *fakeTx) Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error) {
+	*tx.queries = append(*tx.queries, sql)
+	return &errRows{}, nil
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{queries: tx.queries}, nil
+}
+
+// fakeBeginDB is a DB that can begin a fakeTx.
+type fakeBeginDB struct {
+	errDB
+	queries []string
+}
+
+func (db *fakeBeginDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{queries: &db.queries}, nil
+}
+
+func TestDebugWriterTx(t *testing.T) {
+	t.Parallel()
+
+	type beginner interface {
+		Begin(ctx context.Context) (pgx.Tx, error)
+	}
+
+	buf := &bytes.Buffer{}
+	db := &fakeBeginDB{}
+	debugDB := pgxrecord.DebugWriter(db, buf)
+
+	tx, err := debugDB.(beginner).Begin(context.Background())
+	require.NoError(t, err)
+
+	_, err = tx.Query(context.Background(), "select 1")
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "select 1")
+	require.Equal(t, []string{"select 1"}, db.queries)
+
+	debugTx := pgxrecord.DebugWriter(&fakeTx{queries: &db.queries}, buf)
+	require.Implements(t, (*pgx.Tx)(nil), debugTx)
+
+	sp, err := debugTx.(pgx.Tx).Begin(context.Background())
+	require.NoError(t, err)
+
+	_, err = sp.Query(context.Background(), "select 2")
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), "select 2")
+	require.Equal(t, []string{"select 1", "select 2"}, db.queries)
+
+	_, err = pgxrecord.DebugWriter(&errDB{}, buf).(beginner).Begin(context.Background())
+	require.Error(t, err)
+}
+
+// errRows is a pgx.Rows that has no rows and returns err.
+type errRows struct {
+	pgx.Rows
+	err error
+}
+
+func (rows *errRows) Next() bool { return false }
+func (rows *errRows) Err() error { return rows.err }
+func (rows *errRows) Close()     {}
+
+// recordingDB is a DB that records the last query instead of executing it.
+type recordingDB struct {
+	sql  string
+	args []any
+}
+
+func (db *recordingDB) Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error) {
+	db.sql = sql
+	db.args = optionsAndArgs
+	err := errors.New("not executed")
+	return &errRows{err: err}, err
+}
+
+func TestTableExplainSQL(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	record.Set("id", int32(1))
+	record.Set("name", "John")
+
+	for i, tt := range []struct {
+		operation string
+		sql       string
+		args      []any
+	}{
+		{"insert", `explain (format text) insert into "t" ("id", "name") values ($1, $2) returning "id", "name"`, []any{int32(1), "John"}},
+		{"update", `explain (format text) update "t" set "id" = $2, "name" = $3 where "id" = $1 returning "id", "name"`, []any{int32(1), int32(1), "John"}},
+		{"select", `explain (format text) select "t"."id", "t"."name" from "t" where "id" = $1`, []any{int32(1)}},
+		{"delete", `explain (format text) delete from "t" where "id" = $1`, []any{int32(1)}},
+	} {
+		db := &recordingDB{}
+		_, err := table.Explain(context.Background(), db, tt.operation, record)
+		require.Errorf(t, err, "%d", i)
+		require.Equalf(t, tt.sql, db.sql, "%d", i)
+		require.Equalf(t, tt.args, db.args, "%d", i)
+	}
+
+	_, err := table.Explain(context.Background(), &recordingDB{}, "upsert", record)
+	require.ErrorContains(t, err, "unknown operation")
+}
+
+func TestTableExplain(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+		record.Set("name", "John")
+
+		plan, err := table.Explain(ctx, conn, "insert", record)
+		require.NoError(t, err)
+		require.Contains(t, plan, "Insert on t")
+
+		var count int
+		err = conn.QueryRow(ctx, `select count(*) from t`).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+	})
+}
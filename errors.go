@@ -0,0 +1,101 @@
+package pgxrecord
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ConstraintError is returned when a unique or foreign key constraint is violated.
+type ConstraintError struct {
+	Constraint string
+	ColumnName string
+	Err        *pgconn.PgError
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("constraint %q violated: %s", e.Constraint, e.Err.Message)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// IsConstraintError returns the *ConstraintError in err's chain if there is one.
+func IsConstraintError(err error) (*ConstraintError, bool) {
+	var ce *ConstraintError
+	if errors.As(err, &ce) {
+		return ce, true
+	}
+	return nil, false
+}
+
+// wrapConstraintError wraps unique violations and foreign key violations in a *ConstraintError. Other errors are
+// returned unchanged.
+func wrapConstraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505", "23503":
+			return &ConstraintError{Constraint: pgErr.ConstraintName, ColumnName: pgErr.ColumnName, Err: pgErr}
+		}
+	}
+
+	return err
+}
+
+// ErrNotFound matches any *NotFoundError with errors.Is.
+var ErrNotFound = errors.New("not found")
+
+// NotFoundError is returned when a record is not found by primary key or by unique columns. It unwraps to
+// pgx.ErrNoRows and matches ErrNotFound with errors.Is.
+type NotFoundError struct {
+	Table string
+	PK    []any
+
+	// Columns and Values are set instead of PK when the record was searched for by columns other than the primary key.
+	Columns []string
+	Values  []any
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Columns != nil {
+		return fmt.Sprintf("%s with %v = %v not found", e.Table, e.Columns, e.Values)
+	}
+	return fmt.Sprintf("%s with primary key %v not found", e.Table, e.PK)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return pgx.ErrNoRows
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// IsNotFound returns true if err is or wraps a *NotFoundError. It is equivalent to errors.Is(err, ErrNotFound).
+func IsNotFound(err error) bool {
+	var nfe *NotFoundError
+	return errors.As(err, &nfe)
+}
+
+// wrapNotFound converts pgx.ErrNoRows to a *NotFoundError. Other errors are returned unchanged.
+func (t *Table) wrapNotFound(err error, pk []any) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &NotFoundError{Table: t.quotedQualifiedName, PK: pk}
+	}
+
+	return err
+}
+
+// wrapNotFoundByColumns converts pgx.ErrNoRows to a *NotFoundError for a search by columns. Other errors are returned
+// unchanged.
+func (t *Table) wrapNotFoundByColumns(err error, columns []string, values []any) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &NotFoundError{Table: t.quotedQualifiedName, Columns: columns, Values: values}
+	}
+
+	return err
+}
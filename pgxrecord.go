@@ -3,21 +3,42 @@ package pgxrecord
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 var errTooManyRows = fmt.Errorf("too many rows")
+var errNoPrimaryKey = fmt.Errorf("table has no primary key")
+
+// ErrNoColumns is returned when a record is inserted without any assigned attributes.
+var ErrNoColumns = errors.New("no columns assigned")
 
 // DB is the interface pgxrecord uses to access the database. It is satisfied by *pgx.Conn, pgx.Tx, *pgxpool.Pool, etc.
 type DB interface {
 	Query(ctx context.Context, sql string, optionsAndArgs ...interface{}) (pgx.Rows, error)
 }
 
+// ParseDSN parses a PostgreSQL connection string in URL or keyword/value format with pgconn.ParseConfig.
+func ParseDSN(dsn string) (pgconn.Config, error) {
+	config, err := pgconn.ParseConfig(dsn)
+	if err != nil {
+		return pgconn.Config{}, fmt.Errorf("pgxrecord: ParseDSN: %w", err)
+	}
+
+	return *config, nil
+}
+
 // Column represents a column in a table.
 type Column struct {
 	Name       string
@@ -25,6 +46,27 @@ type Column struct {
 	OID        uint32
 	NotNull    bool
 	PrimaryKey bool
+	HasDefault bool // HasDefault is true if the column has a default value or is an identity column.
+}
+
+// Nullable returns true if the column allows null values.
+func (c *Column) Nullable() bool {
+	return !c.NotNull
+}
+
+// IsRequired returns true if the column does not allow null values and is not part of the primary key.
+func (c *Column) IsRequired() bool {
+	return c.NotNull && !c.PrimaryKey
+}
+
+// QuotedName returns the quoted column name for use in SQL. It is set when the table is finalized. It panics if the
+// table has not been finalized.
+func (c *Column) QuotedName() string {
+	if c.quotedName == "" {
+		panic(fmt.Sprintf("pgxrecord.Column (%s): QuotedName: table has not been finalized", c.Name))
+	}
+
+	return c.quotedName
 }
 
 // Table represents a table in a database. It must not be mutated after any method other than LoadAllColumns is called.
@@ -41,16 +83,60 @@ type Table struct {
 	// validating. For example, a database query for a uniqueness check failed because of a broken database connection.
 	Validate func(ctx context.Context, db DB, table *Table, record *Record) error
 
-	finalized           bool
-	quotedQualifiedName string
-	quotedName          string
-	selectQuery         string
-	selectByPKQuery     string
-	pkWhereClause       string
-	returningClause     string
-	pkIndexes           []int
-	nameToColumnIndex   map[string]int
-	validationErrors    *ValidationErrors
+	// IsMaterializedView indicates the table is a materialized view. Records of a materialized view cannot be saved.
+	IsMaterializedView bool
+
+	// IsReadOnly indicates records of the table cannot be saved. It is set by LoadViewColumns.
+	IsReadOnly bool
+
+	// DefaultSaveTimeout is the timeout for the SQL executed by Save. It does not apply to Normalize and Validate. It is
+	// ignored if the context passed to Save already has a deadline. Zero means no timeout.
+	DefaultSaveTimeout time.Duration
+
+	// AuditLogTable is the table Save writes an audit log entry to after a record is inserted or updated. It must have
+	// the columns table_name, record_pk, action, actor, changed_at, and changes. changes should be jsonb. The record and
+	// the entry are written in one transaction, or in a savepoint if db is already a transaction, so db must be able
+	// to begin a transaction. The actor is taken from the context with ContextWithAuditActor.
+	AuditLogTable *Table
+
+	// LoadTimeout is the timeout for LoadAllColumns and LoadColumnsSubset. It prevents startup from hanging when the
+	// database is unresponsive. Zero means no timeout.
+	LoadTimeout time.Duration
+
+	finalized            bool
+	columnsLoaded        bool
+	readOnly             bool
+	noPrimaryKey         bool
+	preparedConn         *pgx.Conn
+	preparedStatements   map[string]string
+	quotedQualifiedName  string
+	quotedName           string
+	selectQuery          string
+	selectQueryFQ        string
+	selectByPKQuery      string
+	selectForUpdateQuery string
+	insertQuery          string
+	updateQuery          string
+	pkWhereClause        string
+	returningClause      string
+	pkIndexes            []int
+	notNullColumns       []*Column
+	pkColumns            []*Column
+	nonPKColumns         []*Column
+	nameToColumnIndex    map[string]int
+	oidToColumns         map[uint32][]*Column
+	computedColumns      []computedColumn
+	nameToComputedIndex  map[string]int
+	namedScopes          map[string]func(*TableQuery) *TableQuery
+
+	uniqueQueriesMutex sync.Mutex
+	uniqueQueries      map[string]string // select queries used by FindByUnique keyed by sorted column names
+}
+
+// computedColumn is a virtual column whose value is computed in Go.
+type computedColumn struct {
+	name string
+	expr func(*Record) any
 }
 
 // Record represents a row from a table in the database.
@@ -59,15 +145,67 @@ type Record struct {
 	originalAttributes []any
 	attributes         []any
 	assigned           []bool
+	validationErrors   *ValidationErrors
+	computedAttributes []any
+}
+
+// NewTable returns a new Table with name and columns. The table is finalized by MustFinalize or the first call to a
+// method that uses it. It panics if name is empty.
+func NewTable(name pgx.Identifier, columns []*Column) *Table {
+	if len(name) == 0 {
+		panic("pgxrecord: NewTable: name is empty")
+	}
+
+	return &Table{Name: name, Columns: columns}
 }
 
 // LoadAllColumns queries the database for the table columns. It must not be called after any other method has been
-// called.
+// called. It returns an error if columns have already been loaded.
 func (t *Table) LoadAllColumns(ctx context.Context, db DB) error {
+	return t.loadColumns(ctx, db, "LoadAllColumns", nil, false)
+}
+
+// LoadViewColumns queries the database for the columns of a view. Views do not have primary keys so no column is
+// marked as a primary key. It sets IsReadOnly so records cannot be saved. It must not be called after any other method
+// has been called.
+func (t *Table) LoadViewColumns(ctx context.Context, db DB) error {
+	err := t.loadColumns(ctx, db, "LoadViewColumns", nil, true)
+	if err != nil {
+		return err
+	}
+
+	t.IsReadOnly = true
+
+	return nil
+}
+
+// LoadColumnsSubset queries the database for the table columns named in columnNames. Only these columns will be
+// selected, inserted, and updated. It returns an error if columnNames is empty or any of the columns do not exist. It
+// must not be called after any other method has been called.
+func (t *Table) LoadColumnsSubset(ctx context.Context, db DB, columnNames []string) error {
+	if len(columnNames) == 0 {
+		return fmt.Errorf("pgxrecord.Table (%s): LoadColumnsSubset: columnNames is empty", t.Name.Sanitize())
+	}
+	return t.loadColumns(ctx, db, "LoadColumnsSubset", columnNames, false)
+}
+
+// loadColumns queries the database for the table columns. If columnNames is nil all columns are loaded. If isView is
+// true primary keys are not queried. methodName is used in error messages.
+func (t *Table) loadColumns(ctx context.Context, db DB, methodName string, columnNames []string, isView bool) error {
 	if t.finalized {
 		return fmt.Errorf("cannot call after table finalized")
 	}
 
+	if t.columnsLoaded {
+		return fmt.Errorf("pgxrecord.Table (%s): %s: columns already loaded", t.Name.Sanitize(), methodName)
+	}
+
+	if t.LoadTimeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.LoadTimeout)
+		defer cancel()
+	}
+
 	var tableOID uint32
 
 	{
@@ -96,39 +234,169 @@ func (t *Table) LoadAllColumns(ctx context.Context, db DB) error {
 		var err error
 		tableOID, err = pgx.CollectOneRow(rows, pgx.RowTo[uint32])
 		if err != nil {
-			return fmt.Errorf("pgxrecord.Table (%s): LoadAllColumns: failed to find table OID: %v", t.Name.Sanitize(), err)
+			return fmt.Errorf("pgxrecord.Table (%s): %s: failed to find table OID: %v", t.Name.Sanitize(), methodName, err)
 		}
 	}
 
-	rows, _ := db.Query(ctx, `select attname, atttypid, attnotnull,
-		coalesce((
+	isPrimarySQL := `coalesce((
 			select true
 			from pg_catalog.pg_index
 			where pg_index.indrelid=pg_attribute.attrelid
 				and pg_index.indisprimary
+				and pg_index.indpred is null
 				and pg_attribute.attnum = any(pg_index.indkey)
-		), false) as isprimary
+		), false)`
+	if isView {
+		isPrimarySQL = `false`
+	}
+
+	rows, _ := db.Query(ctx, `select attname, atttypid, attnotnull,
+		`+isPrimarySQL+` as isprimary,
+		atthasdef or attidentity <> '' as hasdefault
 	from pg_catalog.pg_attribute
 	where attrelid=$1
 		and attnum > 0
 		and not attisdropped
-	order by attnum`, tableOID)
-	var err error
-	t.Columns, err = pgx.CollectRows(rows, pgx.RowToAddrOfStructByPos[Column])
+		and ($2::text[] is null or attname = any($2))
+	order by attnum`, tableOID, columnNames)
+	columns, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByPos[Column])
 	if err != nil {
-		return fmt.Errorf("pgxrecord.Table (%s): LoadAllColumns: failed to find columns: %v", t.Name.Sanitize(), err)
+		return fmt.Errorf("pgxrecord.Table (%s): %s: failed to find columns: %v", t.Name.Sanitize(), methodName, err)
+	}
+
+	if columnNames != nil {
+		foundNames := buildNameToColumnIndex(columns)
+		for _, name := range columnNames {
+			if _, ok := foundNames[name]; !ok {
+				return fmt.Errorf("pgxrecord.Table (%s): %s: column %q is not found", t.Name.Sanitize(), methodName, name)
+			}
+		}
 	}
 
+	t.Columns = columns
+	t.columnsLoaded = true
+
 	return nil
 }
 
+// WithColumns sets the table columns and returns t. It allows a table to be constructed without querying the
+// database. It panics if called after the table is finalized.
+func (t *Table) WithColumns(columns []*Column) *Table {
+	if t.finalized {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): WithColumns: cannot call after table finalized", t.quotedQualifiedName))
+	}
+
+	t.Columns = columns
+	return t
+}
+
+// DropColumn removes the column named name from the table so it is not selected, inserted, or updated. It is useful
+// for excluding columns after LoadAllColumns. It returns an error if the column does not exist or if called after the
+// table is finalized.
+func (t *Table) DropColumn(name string) error {
+	if t.finalized {
+		return fmt.Errorf("pgxrecord.Table (%s): DropColumn: cannot call after table finalized", t.quotedQualifiedName)
+	}
+
+	for i, c := range t.Columns {
+		if c.Name == name {
+			columns := make([]*Column, 0, len(t.Columns)-1)
+			columns = append(columns, t.Columns[:i]...)
+			columns = append(columns, t.Columns[i+1:]...)
+			t.Columns = columns
+			return nil
+		}
+	}
+
+	return fmt.Errorf("pgxrecord.Table (%s): DropColumn: column %q is not found", t.Name.Sanitize(), name)
+}
+
+// MustFinalize finishes the table initialization and returns t. The table must not be mutated afterwards. It is not
+// necessary to call MustFinalize as the table is finalized on first use, but it can be convenient when constructing a
+// table. It panics if the table is misconfigured. e.g. the name is empty or there are duplicate column names.
+func (t *Table) MustFinalize() *Table {
+	if !t.finalized {
+		err := t.finalize()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return t
+}
+
+// AddComputedColumn adds a virtual column named name. Its value is computed by expr whenever a record is read from the
+// database. Computed columns are included in Get and Attributes but are never written to the database. It panics if
+// called after the table is finalized or if name is already used.
+func (t *Table) AddComputedColumn(name string, expr func(*Record) any) {
+	if t.finalized {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): AddComputedColumn: cannot call after table finalized", t.quotedQualifiedName))
+	}
+
+	for _, c := range t.Columns {
+		if c.Name == name {
+			panic(fmt.Sprintf("pgxrecord.Table (%s): AddComputedColumn: column %q already exists", t.Name.Sanitize(), name))
+		}
+	}
+	for _, cc := range t.computedColumns {
+		if cc.name == name {
+			panic(fmt.Sprintf("pgxrecord.Table (%s): AddComputedColumn: computed column %q already exists", t.Name.Sanitize(), name))
+		}
+	}
+
+	t.computedColumns = append(t.computedColumns, computedColumn{name: name, expr: expr})
+}
+
+// WithSchema returns a copy of t in schema. The copy is not finalized. Columns are copied so the tables can be
+// finalized independently. Hooks, computed columns, and named scopes are shared.
+func (t *Table) WithSchema(schema string) *Table {
+	name := pgx.Identifier{schema, t.Name[len(t.Name)-1]}
+
+	columns := make([]*Column, len(t.Columns))
+	for i, c := range t.Columns {
+		columnCopy := *c
+		columns[i] = &columnCopy
+	}
+
+	computedColumns := make([]computedColumn, len(t.computedColumns))
+	copy(computedColumns, t.computedColumns)
+
+	var namedScopes map[string]func(*TableQuery) *TableQuery
+	if t.namedScopes != nil {
+		namedScopes = make(map[string]func(*TableQuery) *TableQuery, len(t.namedScopes))
+		for name, fn := range t.namedScopes {
+			namedScopes[name] = fn
+		}
+	}
+
+	return &Table{
+		Name:               name,
+		Columns:            columns,
+		Normalize:          t.Normalize,
+		Validate:           t.Validate,
+		IsMaterializedView: t.IsMaterializedView,
+		IsReadOnly:         t.IsReadOnly,
+		DefaultSaveTimeout: t.DefaultSaveTimeout,
+		AuditLogTable:      t.AuditLogTable,
+		LoadTimeout:        t.LoadTimeout,
+		computedColumns:    computedColumns,
+		namedScopes:        namedScopes,
+	}
+}
+
 // finalize finishes the table initialization.
-func (t *Table) finalize() {
+func (t *Table) finalize() error {
 	if t.finalized {
 		panic("BUG: cannot call after table finalized")
 	}
 
+	err := t.validateConfig()
+	if err != nil {
+		return err
+	}
+
 	t.finalized = true
+	t.readOnly = t.IsMaterializedView || t.IsReadOnly
 
 	t.quotedQualifiedName = t.Name.Sanitize()
 	t.quotedName = pgx.Identifier{t.Name[len(t.Name)-1]}.Sanitize()
@@ -136,34 +404,101 @@ func (t *Table) finalize() {
 		c.quotedName = pgx.Identifier{c.Name}.Sanitize()
 		if c.PrimaryKey {
 			t.pkIndexes = append(t.pkIndexes, i)
+			t.pkColumns = append(t.pkColumns, c)
+		} else {
+			t.nonPKColumns = append(t.nonPKColumns, c)
+		}
+		if c.NotNull {
+			t.notNullColumns = append(t.notNullColumns, c)
 		}
 	}
 
+	t.noPrimaryKey = len(t.pkIndexes) == 0
 	t.pkWhereClause = t.buildPKWhereClause()
 	t.selectQuery = t.buildSelectQuery()
-	t.selectByPKQuery = t.selectQuery + " " + t.pkWhereClause
+	t.selectQueryFQ = t.buildSelectQueryFullyQualified()
+	if !t.noPrimaryKey {
+		t.selectByPKQuery = t.selectQuery + " " + t.pkWhereClause
+		t.selectForUpdateQuery = t.selectByPKQuery + " for update"
+	}
 	t.returningClause = t.buildReturningClause()
+
+	allAssigned := make([]bool, len(t.Columns))
+	for i := range allAssigned {
+		allAssigned[i] = true
+	}
+	t.insertQuery = t.buildInsertSQL(allAssigned)
+	if !t.noPrimaryKey {
+		t.updateQuery = t.buildUpdateSQL(allAssigned)
+	}
 	t.nameToColumnIndex = buildNameToColumnIndex(t.Columns)
+	t.oidToColumns = buildOIDToColumns(t.Columns)
+	t.nameToComputedIndex = make(map[string]int, len(t.computedColumns))
+	for i, cc := range t.computedColumns {
+		t.nameToComputedIndex[cc.name] = i
+	}
+
+	return nil
+}
+
+// validateConfig returns an error if the table is misconfigured.
+func (t *Table) validateConfig() error {
+	if len(t.Name) == 0 {
+		return errors.New("pgxrecord.Table: name is empty")
+	}
+
+	quotedQualifiedName := t.Name.Sanitize()
+	names := make(map[string]struct{}, len(t.Columns))
+	for i, c := range t.Columns {
+		if c.Name == "" {
+			return fmt.Errorf("pgxrecord.Table (%s): column %d name is empty", quotedQualifiedName, i)
+		}
+		if _, ok := names[c.Name]; ok {
+			return fmt.Errorf("pgxrecord.Table (%s): duplicate column %q", quotedQualifiedName, c.Name)
+		}
+		names[c.Name] = struct{}{}
+	}
+
+	return nil
 }
 
 func (t *Table) buildSelectQuery() string {
 	b := &strings.Builder{}
 	b.WriteString("select ")
+	t.writeSelectColumns(b, t.quotedName)
+	b.WriteString(" from ")
+	b.WriteString(t.quotedQualifiedName)
+
+	return b.String()
+}
+
+func (t *Table) buildSelectQueryFullyQualified() string {
+	b := &strings.Builder{}
+	b.WriteString("select ")
+	t.writeSelectColumns(b, t.quotedQualifiedName)
+	b.WriteString(" from ")
+	b.WriteString(t.quotedQualifiedName)
+
+	return b.String()
+}
+
+// writeSelectColumns writes the column list qualified by qualifier to b.
+func (t *Table) writeSelectColumns(b *strings.Builder, qualifier string) {
 	for i := range t.Columns {
 		if i > 0 {
 			b.WriteString(", ")
 		}
-		b.WriteString(t.quotedName)
+		b.WriteString(qualifier)
 		b.WriteByte('.')
 		b.WriteString(t.Columns[i].quotedName)
 	}
-	b.WriteString(" from ")
-	b.WriteString(t.quotedQualifiedName)
-
-	return b.String()
 }
 
 func (t *Table) buildPKWhereClause() string {
+	if len(t.pkIndexes) == 0 {
+		return ""
+	}
+
 	b := &strings.Builder{}
 	b.WriteString("where ")
 	for i := range t.pkIndexes {
@@ -218,250 +553,1764 @@ func buildNameToColumnIndex(columns []*Column) map[string]int {
 	return m
 }
 
-// NewRecord creates an empty Record.
-func (t *Table) NewRecord() *Record {
-	if !t.finalized {
-		t.finalize()
-	}
-
-	record := &Record{
-		table:      t,
-		attributes: make([]any, len(t.Columns)),
-		assigned:   make([]bool, len(t.Columns)),
+func buildOIDToColumns(columns []*Column) map[uint32][]*Column {
+	m := make(map[uint32][]*Column)
+	for _, c := range columns {
+		m[c.OID] = append(m[c.OID], c)
 	}
-
-	return record
+	return m
 }
 
-// SelectQuery returns the SQL query to select all rows from the table.
-func (t *Table) SelectQuery() string {
+// ColumnsByOID returns all columns with type oid.
+func (t *Table) ColumnsByOID(oid uint32) []*Column {
 	if !t.finalized {
-		t.finalize()
+		t.MustFinalize()
 	}
 
-	return t.selectQuery
+	return t.oidToColumns[oid]
 }
 
-// FindByPK finds a record by primary key.
-func (t *Table) FindByPK(ctx context.Context, db DB, pk ...any) (*Record, error) {
+// ColumnOrder returns the column names in column order.
+func (t *Table) ColumnOrder() []string {
 	if !t.finalized {
-		t.finalize()
+		t.MustFinalize()
 	}
 
-	rows, _ := db.Query(ctx, t.selectByPKQuery, pk...)
-	record, err := pgx.CollectOneRow(rows, t.RowToRecord)
-	if err != nil {
-		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPK (%v): %w", t.quotedQualifiedName, pk, err)
+	names := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
 	}
 
-	return record, nil
+	return names
 }
 
-// RowToRecord is a pgx.RowToFunc that returns a *Record.
-func (t *Table) RowToRecord(row pgx.CollectableRow) (*Record, error) {
+// ColumnIndex returns the index of the column named name in Columns. It can be used with Record.GetByIndex and
+// Record.SetByIndex to avoid looking up the column by name on every access.
+func (t *Table) ColumnIndex(name string) (int, bool) {
 	if !t.finalized {
-		t.finalize()
+		t.MustFinalize()
 	}
 
-	record := t.NewRecord()
+	idx, ok := t.nameToColumnIndex[name]
+	return idx, ok
+}
 
-	ptrsToAttributes := make([]any, len(record.attributes))
-	for i := range record.attributes {
-		ptrsToAttributes[i] = &record.attributes[i]
+// PrimaryKeyColumnNames returns the names of the primary key columns in column order. It returns nil if the table has
+// no primary key.
+func (t *Table) PrimaryKeyColumnNames() []string {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	err := row.Scan(ptrsToAttributes...)
-	if err != nil {
-		return nil, fmt.Errorf("pgxrecord.Table (%s): RowToRecord: %w", t.quotedQualifiedName, err)
+	if len(t.pkIndexes) == 0 {
+		return nil
 	}
 
-	record.originalAttributes = make([]any, len(record.attributes))
-	copy(record.originalAttributes, record.attributes)
+	names := make([]string, len(t.pkIndexes))
+	for i, idx := range t.pkIndexes {
+		names[i] = t.Columns[idx].Name
+	}
 
-	return record, nil
+	return names
 }
 
-// Set sets an attribute to a value. It panics if attribute does not exist.
-func (r *Record) Set(attribute string, value any) {
-	idx, ok := r.table.nameToColumnIndex[attribute]
-	if !ok {
-		panic(fmt.Sprintf("pgxrecord.Record (%s): Set: attribute %q is not found", r.table.quotedQualifiedName, attribute))
+// NotNullColumns returns the columns that do not allow null values. The returned slice must not be modified.
+func (t *Table) NotNullColumns() []*Column {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	r.attributes[idx] = value
-	r.assigned[idx] = true
+	return t.notNullColumns
 }
 
-// Get returns the value of attribute. It panics if attribute does not exist.
-func (r *Record) Get(attribute string) any {
-	idx, ok := r.table.nameToColumnIndex[attribute]
-	if !ok {
-		panic(fmt.Sprintf("pgxrecord.Record (%s): Get: attribute %q is not found", r.table.quotedQualifiedName, attribute))
+// PrimaryKeyColumns returns the primary key columns. The returned slice must not be modified.
+func (t *Table) PrimaryKeyColumns() []*Column {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	return r.attributes[idx]
+	return t.pkColumns
 }
 
-// SetAttributes sets attributes. Ignores attributes that do not exist.
-func (r *Record) SetAttributes(attributes map[string]any) {
-	for k, v := range attributes {
-		idx, ok := r.table.nameToColumnIndex[k]
-		if ok {
-			r.attributes[idx] = v
-			r.assigned[idx] = true
-		}
+// NonPrimaryKeyColumns returns the columns that are not part of the primary key. The returned slice must not be
+// modified.
+func (t *Table) NonPrimaryKeyColumns() []*Column {
+	if !t.finalized {
+		t.MustFinalize()
 	}
+
+	return t.nonPKColumns
 }
 
-// SetAttributesStrict sets attributes. Returns an error if any attributes do not exist.
-func (r *Record) SetAttributesStrict(attributes map[string]any) error {
-	for k, v := range attributes {
-		idx, ok := r.table.nameToColumnIndex[k]
-		if !ok {
-			return fmt.Errorf("pgxrecord.Record (%s): Set: attribute %q is not found", r.table.quotedQualifiedName, k)
-		}
+// NewRecord creates an empty Record.
+func (t *Table) NewRecord() *Record {
+	if !t.finalized {
+		t.MustFinalize()
+	}
 
-		r.attributes[idx] = v
-		r.assigned[idx] = true
+	record := &Record{
+		table:              t,
+		attributes:         make([]any, len(t.Columns)),
+		assigned:           make([]bool, len(t.Columns)),
+		computedAttributes: make([]any, len(t.computedColumns)),
 	}
 
-	return nil
+	return record
 }
 
-// Attributes returns all attributes.
-func (r *Record) Attributes() map[string]any {
-	m := make(map[string]any, len(r.attributes))
-	for i := range r.table.Columns {
-		m[r.table.Columns[i].Name] = r.attributes[i]
+// SelectQuery returns the SQL query to select all rows from the table.
+func (t *Table) SelectQuery() string {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	return m
+	return t.selectQuery
 }
 
-// Save saves the record using db.
-func (r *Record) Save(ctx context.Context, db DB) error {
-	r.table.validationErrors = nil
-
-	if fn := r.table.Normalize; fn != nil {
-		err := fn(ctx, db, r.table, r)
-		if err != nil {
-			return fmt.Errorf("pgxrecord.Record (%s): Save: %w", r.table.quotedQualifiedName, err)
-		}
+// SelectByPKQuery returns the SQL query to select a row by primary key. The primary key values are the arguments in
+// primary key column order. It returns an empty string if the table has no primary key.
+func (t *Table) SelectByPKQuery() string {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	if fn := r.table.Validate; fn != nil {
-		err := fn(ctx, db, r.table, r)
-		if err != nil {
-			var ve *ValidationErrors
-			if errors.As(err, &ve) {
-				r.table.validationErrors = ve
-			}
-			return fmt.Errorf("pgxrecord.Record (%s): Save: %w", r.table.quotedQualifiedName, err)
-		}
+	return t.selectByPKQuery
+}
+
+// SelectForUpdateQuery returns the SQL query to select and lock a row by primary key. It is SelectByPKQuery with for
+// update appended. The primary key values are the arguments in primary key column order. It returns an empty string
+// if the table has no primary key.
+func (t *Table) SelectForUpdateQuery() string {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	var sql string
-	var args []any
+	return t.selectForUpdateQuery
+}
 
-	if r.originalAttributes == nil {
-		sql, args = r.insert(ctx, db)
-	} else {
-		sql, args = r.update(ctx, db)
+// InsertQuery returns the SQL query Save uses to insert a record when all attributes are assigned. The arguments are
+// the attribute values in column order. The query Save actually uses only includes the assigned attributes.
+func (t *Table) InsertQuery() string {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	ptrsToAttributes := make([]any, len(r.attributes))
-	for i := range r.attributes {
-		ptrsToAttributes[i] = &r.attributes[i]
-	}
+	return t.insertQuery
+}
 
-	err := queryRow(ctx, db, sql, args, ptrsToAttributes)
-	if err != nil {
-		return fmt.Errorf("pgxrecord.Record (%s): Save: %w", r.table.quotedQualifiedName, err)
+// UpdateQuery returns the SQL query Save uses to update a record when all attributes are assigned. The arguments are
+// the primary key values followed by the attribute values in column order. The query Save actually uses only includes
+// the assigned attributes. It returns an empty string if the table has no primary key.
+func (t *Table) UpdateQuery() string {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	r.originalAttributes = make([]any, len(r.attributes))
-	copy(r.originalAttributes, r.attributes)
-	for i := range r.assigned {
-		r.assigned[i] = false
+	return t.updateQuery
+}
+
+// ReturningClause returns the returning clause for all columns in column order. e.g. returning "id", "name". It can be
+// appended to custom insert and update statements whose results are scanned into a record.
+func (t *Table) ReturningClause() string {
+	if !t.finalized {
+		t.MustFinalize()
 	}
 
-	return nil
+	return t.returningClause
 }
 
-func (r *Record) insert(ctx context.Context, db DB) (string, []any) {
-	b := &strings.Builder{}
-	b.WriteString("insert into ")
-	b.WriteString(r.table.quotedQualifiedName)
-	b.WriteString(" (")
+// ReturningClauseWith returns the returning clause for all columns followed by extra. extra are raw SQL expressions
+// such as "xmax = 0 as inserted". It panics if any of extra is empty.
+func (t *Table) ReturningClauseWith(extra ...string) string {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(t.returningClause)
+	for i, e := range extra {
+		if e == "" {
+			panic(fmt.Sprintf("pgxrecord.Table (%s): ReturningClauseWith: extra %d is empty", t.quotedQualifiedName, i))
+		}
+		b.WriteString(", ")
+		b.WriteString(e)
+	}
+
+	return b.String()
+}
+
+// PKWhereClause returns the where clause that matches a row by primary key. e.g. where "id" = $1. The parameters are
+// numbered from $1 in primary key column order so it must be combined with SQL that has no other parameters before
+// it. e.g. "delete from t " + table.PKWhereClause(). It returns an empty string if the table has no primary key.
+func (t *Table) PKWhereClause() string {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	return t.pkWhereClause
+}
+
+// SelectQueryFullyQualified returns the SQL query to select all rows from the table with columns qualified by the
+// fully qualified table name.
+func (t *Table) SelectQueryFullyQualified() string {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	return t.selectQueryFQ
+}
+
+// SelectQueryWhere returns the SQL query to select all rows from the table with whereSQL as the where clause. whereSQL
+// must not include the where keyword. It panics if whereSQL is empty.
+func (t *Table) SelectQueryWhere(whereSQL string) string {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if whereSQL == "" {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): SelectQueryWhere: whereSQL is empty", t.quotedQualifiedName))
+	}
+
+	return t.selectQuery + " where " + whereSQL
+}
+
+// SelectQueryOrderBy returns the SQL query to select all rows from the table ordered by orderSQL. orderSQL must not
+// include the order by keywords. It panics if orderSQL is empty.
+func (t *Table) SelectQueryOrderBy(orderSQL string) string {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if orderSQL == "" {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): SelectQueryOrderBy: orderSQL is empty", t.quotedQualifiedName))
+	}
+
+	return t.selectQuery + " order by " + orderSQL
+}
+
+// SelectQueryWithAlias returns the SQL query to select all rows from the table with the table aliased as alias. This
+// allows the query to be safely combined with joins to other tables with the same name.
+func (t *Table) SelectQueryWithAlias(alias string) string {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	quotedAlias := pgx.Identifier{alias}.Sanitize()
+
+	b := &strings.Builder{}
+	b.WriteString("select ")
+	t.writeSelectColumns(b, quotedAlias)
+	b.WriteString(" from ")
+	b.WriteString(t.quotedQualifiedName)
+	b.WriteString(" as ")
+	b.WriteString(quotedAlias)
+
+	return b.String()
+}
+
+// SelectJoin selects records from the table joined to other tables by joinSQL. joinSQL must begin with join, left join,
+// right join, or inner join and may be followed by where and order by clauses. Only the table's own columns are
+// selected.
+func (t *Table) SelectJoin(ctx context.Context, db DB, joinSQL string, args ...any) ([]*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if !isJoinSQL(joinSQL) {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): SelectJoin: joinSQL must begin with join, left join, right join, or inner join", t.quotedQualifiedName)
+	}
+
+	rows, _ := db.Query(ctx, t.selectQuery+" "+joinSQL, args...)
+	records, err := pgx.CollectRows(rows, t.RowToRecord)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): SelectJoin: %w", t.quotedQualifiedName, err)
+	}
+
+	return records, nil
+}
+
+// SelectDistinct selects one record for each distinct combination of distinctColumns with select distinct on. Records
+// are ordered by distinctColumns. whereSQL must not include the where keyword. If whereSQL is empty all rows are
+// considered. It returns an error if any of distinctColumns is not a column of the table.
+func (t *Table) SelectDistinct(ctx context.Context, db DB, distinctColumns []string, whereSQL string, args ...any) ([]*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if len(distinctColumns) == 0 {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): SelectDistinct: distinctColumns is empty", t.quotedQualifiedName)
+	}
+
+	quotedDistinctColumns := make([]string, len(distinctColumns))
+	for i, name := range distinctColumns {
+		idx, ok := t.nameToColumnIndex[name]
+		if !ok {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): SelectDistinct: column %q is not found", t.quotedQualifiedName, name)
+		}
+		quotedDistinctColumns[i] = t.quotedName + "." + t.Columns[idx].quotedName
+	}
+	distinctList := strings.Join(quotedDistinctColumns, ", ")
+
+	b := &strings.Builder{}
+	b.WriteString("select distinct on (")
+	b.WriteString(distinctList)
+	b.WriteString(") ")
+	t.writeSelectColumns(b, t.quotedName)
+	b.WriteString(" from ")
+	b.WriteString(t.quotedQualifiedName)
+	if whereSQL != "" {
+		b.WriteString(" where ")
+		b.WriteString(whereSQL)
+	}
+	b.WriteString(" order by ")
+	b.WriteString(distinctList)
+
+	rows, _ := db.Query(ctx, b.String(), args...)
+	records, err := pgx.CollectRows(rows, t.RowToRecord)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): SelectDistinct: %w", t.quotedQualifiedName, err)
+	}
+
+	return records, nil
+}
+
+// SortColumn is a column and direction to order by.
+type SortColumn struct {
+	Name string
+	Desc bool
+}
+
+// FindAllOrdered selects records ordered by orderColumns. whereSQL must not include the where keyword. If whereSQL is
+// empty all records are selected. It returns an error if any of orderColumns is not a column of the table.
+func (t *Table) FindAllOrdered(ctx context.Context, db DB, orderColumns []SortColumn, whereSQL string, args ...any) ([]*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if len(orderColumns) == 0 {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindAllOrdered: orderColumns is empty", t.quotedQualifiedName)
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(t.selectQuery)
+	if whereSQL != "" {
+		b.WriteString(" where ")
+		b.WriteString(whereSQL)
+	}
+	b.WriteString(" order by ")
+	for i, sc := range orderColumns {
+		idx, ok := t.nameToColumnIndex[sc.Name]
+		if !ok {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): FindAllOrdered: column %q is not found", t.quotedQualifiedName, sc.Name)
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(t.quotedName)
+		b.WriteByte('.')
+		b.WriteString(t.Columns[idx].quotedName)
+		if sc.Desc {
+			b.WriteString(" desc")
+		}
+	}
+
+	rows, _ := db.Query(ctx, b.String(), args...)
+	records, err := pgx.CollectRows(rows, t.RowToRecord)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindAllOrdered: %w", t.quotedQualifiedName, err)
+	}
+
+	return records, nil
+}
+
+func isJoinSQL(sql string) bool {
+	fields := strings.Fields(strings.ToLower(sql))
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch fields[0] {
+	case "join":
+		return true
+	case "left", "right", "inner":
+		return len(fields) > 1 && fields[1] == "join"
+	}
+
+	return false
+}
+
+// FindByPK finds a record by primary key.
+func (t *Table) FindByPK(ctx context.Context, db DB, pk ...any) (*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.noPrimaryKey {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPK: %w", t.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	rows, _ := db.Query(ctx, t.statementSQL(db, t.selectByPKQuery), pk...)
+	record, err := pgx.CollectOneRow(rows, t.RowToRecord)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPK (%v): %w", t.quotedQualifiedName, pk, t.wrapNotFound(err, pk))
+	}
+
+	return record, nil
+}
+
+// Lock finds a record by primary key and locks the row with select for update. db should be a transaction as the lock
+// is released when the transaction ends. Use FindByPK to find a record without locking it.
+func (t *Table) Lock(ctx context.Context, db DB, pk ...any) (*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.noPrimaryKey {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): Lock: %w", t.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	rows, _ := db.Query(ctx, t.selectForUpdateQuery, pk...)
+	record, err := pgx.CollectOneRow(rows, t.RowToRecord)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): Lock (%v): %w", t.quotedQualifiedName, pk, t.wrapNotFound(err, pk))
+	}
+
+	return record, nil
+}
+
+// FindByUnique finds a record where uniqueColumns equal values. uniqueColumns should be the columns of a unique
+// constraint or index. If no row matches it returns a *NotFoundError with Columns and Values set. If more than one row
+// matches an error is returned.
+func (t *Table) FindByUnique(ctx context.Context, db DB, uniqueColumns []string, values []any) (*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if len(uniqueColumns) == 0 {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByUnique: uniqueColumns is empty", t.quotedQualifiedName)
+	}
+
+	if len(uniqueColumns) != len(values) {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByUnique: %d columns but %d values", t.quotedQualifiedName, len(uniqueColumns), len(values))
+	}
+
+	valuesByName := make(map[string]any, len(uniqueColumns))
+	for i, name := range uniqueColumns {
+		if _, ok := t.nameToColumnIndex[name]; !ok {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): FindByUnique: column %q is not found", t.quotedQualifiedName, name)
+		}
+		if _, ok := valuesByName[name]; ok {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): FindByUnique: duplicate column %q", t.quotedQualifiedName, name)
+		}
+		valuesByName[name] = values[i]
+	}
+
+	names := make([]string, len(uniqueColumns))
+	copy(names, uniqueColumns)
+	sort.Strings(names)
+
+	args := make([]any, len(names))
+	for i, name := range names {
+		args[i] = valuesByName[name]
+	}
+
+	rows, _ := db.Query(ctx, t.findByUniqueQuery(names), args...)
+	records, err := pgx.CollectRows(rows, t.RowToRecord)
+	if err == nil {
+		switch len(records) {
+		case 0:
+			err = pgx.ErrNoRows
+		case 1:
+			return records[0], nil
+		default:
+			err = errTooManyRows
+		}
+	}
+
+	return nil, fmt.Errorf("pgxrecord.Table (%s): FindByUnique (%v): %w", t.quotedQualifiedName, values, t.wrapNotFoundByColumns(err, uniqueColumns, values))
+}
+
+// findByUniqueQuery returns the query to select a row where sortedNames equal the arguments in order. Queries are
+// cached.
+func (t *Table) findByUniqueQuery(sortedNames []string) string {
+	key := strings.Join(sortedNames, ",")
+
+	t.uniqueQueriesMutex.Lock()
+	defer t.uniqueQueriesMutex.Unlock()
+
+	if sql, ok := t.uniqueQueries[key]; ok {
+		return sql
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(t.selectQuery)
+	b.WriteString(" where ")
+	for i, name := range sortedNames {
+		if i > 0 {
+			b.WriteString(" and ")
+		}
+		b.WriteString(t.quotedName)
+		b.WriteByte('.')
+		b.WriteString(t.Columns[t.nameToColumnIndex[name]].quotedName)
+		b.WriteString(" = $")
+		b.WriteString(strconv.FormatInt(int64(i+1), 10))
+	}
+	b.WriteString(" limit 2")
+
+	sql := b.String()
+	if t.uniqueQueries == nil {
+		t.uniqueQueries = make(map[string]string)
+	}
+	t.uniqueQueries[key] = sql
+
+	return sql
+}
+
+// FindByPKBatch finds records by primary key with a single query. Each element of pks is the primary key values of one
+// record. The returned slice has the same length and order as pks. If a record is not found its position is nil.
+func (t *Table) FindByPKBatch(ctx context.Context, db DB, pks [][]any) ([]*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.noPrimaryKey {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPKBatch: %w", t.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	results := make([]*Record, len(pks))
+	if len(pks) == 0 {
+		return results, nil
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(t.selectQuery)
+	b.WriteString(" where (")
+	for i, pkIdx := range t.pkIndexes {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(t.quotedName)
+		b.WriteByte('.')
+		b.WriteString(t.Columns[pkIdx].quotedName)
+	}
+	b.WriteString(") in (")
+
+	args := make([]any, 0, len(pks)*len(t.pkIndexes))
+	for i, pk := range pks {
+		if len(pk) != len(t.pkIndexes) {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPKBatch: pks[%d] has %d values, expected %d", t.quotedQualifiedName, i, len(pk), len(t.pkIndexes))
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j, v := range pk {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			args = append(args, v)
+			b.WriteByte('$')
+			b.WriteString(strconv.FormatInt(int64(len(args)), 10))
+		}
+		b.WriteByte(')')
+	}
+	b.WriteByte(')')
+
+	rows, _ := db.Query(ctx, b.String(), args...)
+	records, err := pgx.CollectRows(rows, t.RowToRecord)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPKBatch: %w", t.quotedQualifiedName, err)
+	}
+
+	// Match records to pks by the text encoding of the primary key so that values of different Go types that represent
+	// the same PostgreSQL value (e.g. int and int32) are equal.
+	m := pgtype.NewMap()
+	recordsByKey := make(map[string]*Record, len(records))
+	for _, record := range records {
+		key, err := t.pkKey(m, t.PKValues(record))
+		if err != nil {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPKBatch: %w", t.quotedQualifiedName, err)
+		}
+		recordsByKey[key] = record
+	}
+
+	for i, pk := range pks {
+		key, err := t.pkKey(m, pk)
+		if err != nil {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPKBatch: %w", t.quotedQualifiedName, err)
+		}
+		results[i] = recordsByKey[key]
+	}
+
+	return results, nil
+}
+
+// pkKey returns a string that uniquely identifies the primary key values pk.
+func (t *Table) pkKey(m *pgtype.Map, pk []any) (string, error) {
+	b := &strings.Builder{}
+	var buf []byte
+	for i, pkIdx := range t.pkIndexes {
+		var err error
+		buf, err = m.Encode(t.Columns[pkIdx].OID, pgtype.TextFormatCode, pk[i], buf[:0])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strconv.Itoa(len(buf)))
+		b.WriteByte(':')
+		b.Write(buf)
+	}
+
+	return b.String(), nil
+}
+
+// FindByPKTyped finds a row of table by primary key and returns it as a *T. Columns are mapped to struct fields by
+// name in the same way as pgx.RowToStructByName.
+func FindByPKTyped[T any](ctx context.Context, db DB, table *Table, pk ...any) (*T, error) {
+	if !table.finalized {
+		table.MustFinalize()
+	}
+
+	if table.noPrimaryKey {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPKTyped: %w", table.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	rows, _ := db.Query(ctx, table.statementSQL(db, table.selectByPKQuery), pk...)
+	value, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByName[T])
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): FindByPKTyped (%v): %w", table.quotedQualifiedName, pk, table.wrapNotFound(err, pk))
+	}
+
+	return value, nil
+}
+
+// FindOrCreate finds a record matching searchAttrs. If no record is found then a new record is created with
+// searchAttrs and createAttrs and saved. The returned bool is true if the record was created. searchAttrs must not be
+// empty.
+//
+// There is a race between finding and creating a record. If the insert fails with a unique violation then it is
+// assumed that another connection created a matching record concurrently and the find is retried. When db is a pgx.Tx
+// the insert is run in a savepoint so that a failed insert does not abort the enclosing transaction. The table should
+// have a unique constraint covering searchAttrs for this to be effective.
+func (t *Table) FindOrCreate(ctx context.Context, db DB, searchAttrs, createAttrs map[string]any) (*Record, bool, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if len(searchAttrs) == 0 {
+		return nil, false, fmt.Errorf("pgxrecord.Table (%s): FindOrCreate: searchAttrs is empty", t.quotedQualifiedName)
+	}
+
+	record, err := t.findOneByAttributes(ctx, db, searchAttrs)
+	if err == nil {
+		return record, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("pgxrecord.Table (%s): FindOrCreate: %w", t.quotedQualifiedName, err)
+	}
+
+	record = t.NewRecord()
+	err = record.SetAttributesStrict(searchAttrs)
+	if err != nil {
+		return nil, false, fmt.Errorf("pgxrecord.Table (%s): FindOrCreate: %w", t.quotedQualifiedName, err)
+	}
+	err = record.SetAttributesStrict(createAttrs)
+	if err != nil {
+		return nil, false, fmt.Errorf("pgxrecord.Table (%s): FindOrCreate: %w", t.quotedQualifiedName, err)
+	}
+
+	err = inSavepoint(ctx, db, func(db DB) error { return record.Save(ctx, db) })
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			record, findErr := t.findOneByAttributes(ctx, db, searchAttrs)
+			if findErr == nil {
+				return record, false, nil
+			}
+		}
+		return nil, false, fmt.Errorf("pgxrecord.Table (%s): FindOrCreate: %w", t.quotedQualifiedName, err)
+	}
+
+	return record, true, nil
+}
+
+// findOneByAttributes finds the first record where all columns equal the values in attributes.
+func (t *Table) findOneByAttributes(ctx context.Context, db DB, attributes map[string]any) (*Record, error) {
+	names := make([]string, 0, len(attributes))
+	for k := range attributes {
+		if _, ok := t.nameToColumnIndex[k]; !ok {
+			return nil, fmt.Errorf("attribute %q is not found", k)
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	b := &strings.Builder{}
+	b.WriteString(t.selectQuery)
+	args := make([]any, 0, len(names))
+	for i, name := range names {
+		if i == 0 {
+			b.WriteString(" where ")
+		} else {
+			b.WriteString(" and ")
+		}
+		b.WriteString(t.quotedName)
+		b.WriteByte('.')
+		b.WriteString(t.Columns[t.nameToColumnIndex[name]].quotedName)
+		if attributes[name] == nil {
+			b.WriteString(" is null")
+		} else {
+			args = append(args, attributes[name])
+			b.WriteString(" = $")
+			b.WriteString(strconv.FormatInt(int64(len(args)), 10))
+		}
+	}
+	b.WriteString(" limit 1")
+
+	rows, _ := db.Query(ctx, b.String(), args...)
+	return pgx.CollectOneRow(rows, t.RowToRecord)
+}
+
+// beginner is a DB that can begin a transaction. It is satisfied by *pgx.Conn, pgx.Tx, *pgxpool.Pool, etc.
+type beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// inTransaction calls fn in a transaction. If db is already a pgx.Tx then fn is run in a savepoint. It returns an
+// error if db cannot begin a transaction.
+func inTransaction(ctx context.Context, db DB, fn func(DB) error) error {
+	b, ok := db.(beginner)
+	if !ok {
+		return fmt.Errorf("db does not support transactions")
+	}
+
+	tx, err := b.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// inSavepoint calls fn with db. If db is a pgx.Tx then fn is run in a savepoint so an error does not abort the
+// transaction.
+func inSavepoint(ctx context.Context, db DB, fn func(DB) error) error {
+	tx, ok := db.(pgx.Tx)
+	if !ok {
+		return fn(db)
+	}
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer sp.Rollback(ctx)
+
+	err = fn(sp)
+	if err != nil {
+		return err
+	}
+
+	return sp.Commit(ctx)
+}
+
+// PKValues returns the primary key values of record in primary key order. It panics if record does not belong to t.
+func (t *Table) PKValues(record *Record) []any {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): PKValues: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName))
+	}
+
+	return t.pkValuesOf(record.attributes)
+}
+
+// pkValuesOf returns the primary key values in attributes.
+func (t *Table) pkValuesOf(attributes []any) []any {
+	pk := make([]any, len(t.pkIndexes))
+	for i, pkIdx := range t.pkIndexes {
+		pk[i] = attributes[pkIdx]
+	}
+
+	return pk
+}
+
+// Refresh reloads record from the database by primary key. Any unsaved changes to record are discarded. record must
+// belong to t. It returns an error without querying the database if any primary key attribute is null or the zero
+// value of its type.
+func (t *Table) Refresh(ctx context.Context, db DB, record *Record) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return fmt.Errorf("pgxrecord.Table (%s): Refresh: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	if t.noPrimaryKey {
+		return fmt.Errorf("pgxrecord.Table (%s): Refresh: %w", t.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	pk := t.PKValues(record)
+	for i, v := range pk {
+		if v == nil || reflect.ValueOf(v).IsZero() {
+			return fmt.Errorf("pgxrecord.Table (%s): Refresh: primary key %q is null or zero", t.quotedQualifiedName, t.Columns[t.pkIndexes[i]].Name)
+		}
+	}
+
+	ptrsToAttributes := make([]any, len(record.attributes))
+	for i := range record.attributes {
+		ptrsToAttributes[i] = &record.attributes[i]
+	}
+
+	err := queryRow(ctx, db, t.statementSQL(db, t.selectByPKQuery), pk, ptrsToAttributes)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): Refresh (%v): %w", t.quotedQualifiedName, pk, t.wrapNotFound(err, pk))
+	}
+
+	record.originalAttributes = make([]any, len(record.attributes))
+	copy(record.originalAttributes, record.attributes)
+	for i := range record.assigned {
+		record.assigned[i] = false
+	}
+	record.computeAttributes()
+
+	return nil
+}
+
+// RowToRecord is a pgx.RowToFunc that returns a *Record.
+func (t *Table) RowToRecord(row pgx.CollectableRow) (*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	record := t.NewRecord()
+	err := scanRecord(row, record)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): RowToRecord: %w", t.quotedQualifiedName, err)
+	}
+
+	return record, nil
+}
+
+// Collect scans each row of rows into a *Record of table, converts it with convert, and returns the results. rows must
+// select the same columns as table.SelectQuery. rows is closed when Collect returns.
+func Collect[T any](rows pgx.Rows, table *Table, convert func(*Record) T) ([]T, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (T, error) {
+		record, err := table.RowToRecord(row)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+
+		return convert(record), nil
+	})
+}
+
+// ScanAll scans each row of rows with fn and returns the results. fn can be any pgx.RowToFunc such as
+// table.RowToRecord or pgx.RowToStructByName. rows is closed when ScanAll returns.
+func ScanAll[T any](rows pgx.Rows, fn func(pgx.CollectableRow) (T, error)) ([]T, error) {
+	results, err := pgx.CollectRows(rows, fn)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord: ScanAll: %w", err)
+	}
+
+	return results, nil
+}
+
+// scanRecord scans row into record and marks record as persisted.
+func scanRecord(row pgx.CollectableRow, record *Record) error {
+	ptrsToAttributes := make([]any, len(record.attributes))
+	for i := range record.attributes {
+		ptrsToAttributes[i] = &record.attributes[i]
+	}
+
+	err := row.Scan(ptrsToAttributes...)
+	if err != nil {
+		return err
+	}
+
+	record.originalAttributes = make([]any, len(record.attributes))
+	copy(record.originalAttributes, record.attributes)
+	record.computeAttributes()
+
+	return nil
+}
+
+// computeAttributes evaluates the computed columns.
+func (r *Record) computeAttributes() {
+	for i, cc := range r.table.computedColumns {
+		r.computedAttributes[i] = cc.expr(r)
+	}
+}
+
+// RefreshMaterializedView refreshes the materialized view. If concurrently is true then the refresh is run
+// concurrently.
+func (t *Table) RefreshMaterializedView(ctx context.Context, db DB, concurrently bool) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if !t.IsMaterializedView {
+		return fmt.Errorf("pgxrecord.Table (%s): RefreshMaterializedView: table is not a materialized view", t.quotedQualifiedName)
+	}
+
+	sql := "refresh materialized view "
+	if concurrently {
+		sql += "concurrently "
+	}
+	sql += t.quotedQualifiedName
+
+	err := exec(ctx, db, sql, nil)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): RefreshMaterializedView: %w", t.quotedQualifiedName, err)
+	}
+
+	return nil
+}
+
+// Set sets an attribute to a value. It panics if attribute does not exist.
+func (r *Record) Set(attribute string, value any) {
+	idx, ok := r.table.nameToColumnIndex[attribute]
+	if !ok {
+		panic(fmt.Sprintf("pgxrecord.Record (%s): Set: attribute %q is not found", r.table.quotedQualifiedName, attribute))
+	}
+
+	r.attributes[idx] = value
+	r.assigned[idx] = true
+}
+
+// SetByIndex sets the attribute of the column at idx in Columns to value. It panics if idx is out of range.
+func (r *Record) SetByIndex(idx int, value any) {
+	r.attributes[idx] = value
+	r.assigned[idx] = true
+}
+
+// GetByIndex returns the value of the attribute of the column at idx in Columns. It panics if idx is out of range.
+func (r *Record) GetByIndex(idx int) any {
+	return r.attributes[idx]
+}
+
+// SetNil sets an attribute to SQL null. It panics if attribute does not exist or the column does not allow null values.
+func (r *Record) SetNil(attribute string) {
+	idx, ok := r.table.nameToColumnIndex[attribute]
+	if !ok {
+		panic(fmt.Sprintf("pgxrecord.Record (%s): SetNil: attribute %q is not found", r.table.quotedQualifiedName, attribute))
+	}
+
+	if r.table.Columns[idx].NotNull {
+		panic(fmt.Sprintf("pgxrecord.Record (%s): SetNil: attribute %q does not allow null values", r.table.quotedQualifiedName, attribute))
+	}
+
+	r.attributes[idx] = nil
+	r.assigned[idx] = true
+}
+
+// SetIfEmpty sets an attribute to value only if its current value is nil, an empty string, or a driver.Valuer such as a
+// pgtype value that returns nil. It is useful for setting defaults in Normalize. It panics if attribute does not exist.
+func (r *Record) SetIfEmpty(attribute string, value any) {
+	if isBlank(r.Get(attribute)) {
+		r.Set(attribute, value)
+	}
+}
+
+// Get returns the value of attribute. It panics if attribute does not exist.
+func (r *Record) Get(attribute string) any {
+	idx, ok := r.table.nameToColumnIndex[attribute]
+	if !ok {
+		if idx, ok := r.table.nameToComputedIndex[attribute]; ok {
+			return r.computedAttributes[idx]
+		}
+		panic(fmt.Sprintf("pgxrecord.Record (%s): Get: attribute %q is not found", r.table.quotedQualifiedName, attribute))
+	}
+
+	return r.attributes[idx]
+}
+
+// GetTyped returns the value of attribute as a T. The bool is false if the value is not a T. It panics if attribute
+// does not exist.
+func GetTyped[T any](r *Record, attribute string) (T, bool) {
+	value, ok := r.Get(attribute).(T)
+	return value, ok
+}
+
+// SetAttributes sets attributes. Ignores attributes that do not exist.
+func (r *Record) SetAttributes(attributes map[string]any) {
+	for k, v := range attributes {
+		idx, ok := r.table.nameToColumnIndex[k]
+		if ok {
+			r.attributes[idx] = v
+			r.assigned[idx] = true
+		}
+	}
+}
+
+// SetAttributesStrict sets attributes. Returns an error if any attributes do not exist.
+func (r *Record) SetAttributesStrict(attributes map[string]any) error {
+	for k, v := range attributes {
+		idx, ok := r.table.nameToColumnIndex[k]
+		if !ok {
+			return fmt.Errorf("pgxrecord.Record (%s): Set: attribute %q is not found", r.table.quotedQualifiedName, k)
+		}
+
+		r.attributes[idx] = v
+		r.assigned[idx] = true
+	}
+
+	return nil
+}
+
+// Attributes returns all attributes.
+func (r *Record) Attributes() map[string]any {
+	m := make(map[string]any, len(r.attributes)+len(r.computedAttributes))
+	for i := range r.table.Columns {
+		m[r.table.Columns[i].Name] = r.attributes[i]
+	}
+	for i, cc := range r.table.computedColumns {
+		m[cc.name] = r.computedAttributes[i]
+	}
+
+	return m
+}
+
+// AttributeNames returns the names of all attributes in column order.
+func (r *Record) AttributeNames() []string {
+	return r.table.ColumnOrder()
+}
+
+// AssignedAttributeNames returns the names of the attributes that have been assigned since the record was last read or
+// saved in column order.
+func (r *Record) AssignedAttributeNames() []string {
+	var names []string
+	for i, c := range r.table.Columns {
+		if r.assigned[i] {
+			names = append(names, c.Name)
+		}
+	}
+
+	return names
+}
+
+// RecordToMap returns the attributes of r. It is equivalent to r.Attributes().
+func RecordToMap(r *Record) map[string]any {
+	return r.Attributes()
+}
+
+// RecordsToMaps returns the attributes of each record in records.
+func RecordsToMaps(records []*Record) []map[string]any {
+	maps := make([]map[string]any, len(records))
+	for i, r := range records {
+		maps[i] = RecordToMap(r)
+	}
+
+	return maps
+}
+
+// EachRecord calls fn for each record in records sequentially in order. Iteration stops at the first error and that
+// error is returned.
+func EachRecord(records []*Record, fn func(*Record) error) error {
+	for _, r := range records {
+		err := fn(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopyRecord copies all attributes of src to dst. Attributes assigned in src are marked as assigned in dst. The original
+// attributes of dst are unchanged so Save inserts or updates dst as before. It returns an error if dst and src belong
+// to different tables.
+func CopyRecord(dst, src *Record) error {
+	if dst.table != src.table {
+		return fmt.Errorf("pgxrecord: CopyRecord: dst belongs to table %s but src belongs to table %s", dst.table.quotedQualifiedName, src.table.quotedQualifiedName)
+	}
+
+	copy(dst.attributes, src.attributes)
+	for i := range src.assigned {
+		if src.assigned[i] {
+			dst.assigned[i] = true
+		}
+	}
+	dst.computeAttributes()
+
+	return nil
+}
+
+// Scan copies the record attributes in column order into dst. It has the same semantics as pgx.Row.Scan. Values are
+// converted by encoding them as the column type and scanning the result into dst. For columns without an OID the
+// type is determined from the Go type of the attribute.
+func (r *Record) Scan(dst ...any) error {
+	if len(dst) != len(r.attributes) {
+		return fmt.Errorf("pgxrecord.Record (%s): Scan: number of destinations must be %d, got %d", r.table.quotedQualifiedName, len(r.attributes), len(dst))
+	}
+
+	m := pgtype.NewMap()
+	var buf []byte
+	for i, d := range dst {
+		if d == nil {
+			continue
+		}
+
+		oid := r.table.Columns[i].OID
+		if oid == 0 {
+			if r.attributes[i] == nil {
+				oid = pgtype.TextOID
+			} else {
+				dt, ok := m.TypeForValue(r.attributes[i])
+				if !ok {
+					return fmt.Errorf("pgxrecord.Record (%s): Scan: attribute %q: cannot determine type of %T", r.table.quotedQualifiedName, r.table.Columns[i].Name, r.attributes[i])
+				}
+				oid = dt.OID
+			}
+		}
+
+		var err error
+		buf, err = m.Encode(oid, pgtype.BinaryFormatCode, r.attributes[i], buf[:0])
+		if err != nil {
+			return fmt.Errorf("pgxrecord.Record (%s): Scan: attribute %q: %w", r.table.quotedQualifiedName, r.table.Columns[i].Name, err)
+		}
+
+		err = m.Scan(oid, pgtype.BinaryFormatCode, buf, d)
+		if err != nil {
+			return fmt.Errorf("pgxrecord.Record (%s): Scan: attribute %q: %w", r.table.quotedQualifiedName, r.table.Columns[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON marshals the record attributes as a JSON object.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Attributes())
+}
+
+// UnmarshalJSON sets attributes from a JSON object. The column type is used to determine the Go type of each value.
+// Computed columns are ignored. Returns an error if any other attributes do not exist. r must have been created by a Table.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	if r.table == nil {
+		return fmt.Errorf("pgxrecord.Record: UnmarshalJSON: record does not belong to a table")
+	}
+
+	var rawAttributes map[string]json.RawMessage
+	err := json.Unmarshal(data, &rawAttributes)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Record (%s): UnmarshalJSON: %w", r.table.quotedQualifiedName, err)
+	}
+
+	attributes := make(map[string]any, len(rawAttributes))
+	for k, raw := range rawAttributes {
+		idx, ok := r.table.nameToColumnIndex[k]
+		if !ok {
+			if _, ok := r.table.nameToComputedIndex[k]; ok {
+				continue
+			}
+			return fmt.Errorf("pgxrecord.Record (%s): UnmarshalJSON: attribute %q is not found", r.table.quotedQualifiedName, k)
+		}
+
+		value, err := unmarshalJSONValue(r.table.Columns[idx].OID, raw)
+		if err != nil {
+			return fmt.Errorf("pgxrecord.Record (%s): UnmarshalJSON: attribute %q: %w", r.table.quotedQualifiedName, k, err)
+		}
+		attributes[k] = value
+	}
+
+	return r.SetAttributesStrict(attributes)
+}
+
+// unmarshalJSONValue unmarshals data into the Go type that pgx would scan a value of type oid into.
+func unmarshalJSONValue(oid uint32, data json.RawMessage) (any, error) {
+	if string(data) == "null" {
+		return nil, nil
+	}
+
+	var dst any
+	switch oid {
+	case pgtype.BoolOID:
+		dst = new(bool)
+	case pgtype.Int2OID:
+		dst = new(int16)
+	case pgtype.Int4OID:
+		dst = new(int32)
+	case pgtype.Int8OID:
+		dst = new(int64)
+	case pgtype.Float4OID:
+		dst = new(float32)
+	case pgtype.Float8OID:
+		dst = new(float64)
+	case pgtype.TextOID, pgtype.VarcharOID, pgtype.BPCharOID, pgtype.NameOID:
+		dst = new(string)
+	case pgtype.DateOID, pgtype.TimestampOID, pgtype.TimestamptzOID:
+		dst = new(time.Time)
+	default:
+		var value any
+		err := json.Unmarshal(data, &value)
+		return value, err
+	}
+
+	err := json.Unmarshal(data, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return reflect.ValueOf(dst).Elem().Interface(), nil
+}
+
+// Save saves the record using db. A new record is inserted and an existing record is updated. Only assigned
+// attributes are written. Inserting a record with no assigned attributes returns ErrNoColumns. Updating a record with no
+// assigned attributes does nothing and returns nil.
+func (r *Record) Save(ctx context.Context, db DB) error {
+	if r.table.readOnly {
+		return fmt.Errorf("pgxrecord.Record (%s): Save: table is read-only", r.table.quotedQualifiedName)
+	}
+
+	if r.originalAttributes != nil && r.table.noPrimaryKey {
+		return fmt.Errorf("pgxrecord.Record (%s): Save: %w", r.table.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	err := r.normalizeAndValidate(ctx, db)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Record (%s): Save: %w", r.table.quotedQualifiedName, err)
+	}
+
+	err = r.write(ctx, db)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Record (%s): Save: %w", r.table.quotedQualifiedName, err)
+	}
+
+	return nil
+}
+
+// write inserts or updates the record without calling the Normalize and Validate hooks.
+func (r *Record) write(ctx context.Context, db DB) error {
+	if !r.anyAssigned() {
+		if r.originalAttributes == nil {
+			return ErrNoColumns
+		}
+		return nil
+	}
+
+	var sql string
+	var args []any
+	var action string
+
+	if r.originalAttributes == nil {
+		sql, args = r.insert(ctx, db)
+		action = "insert"
+	} else {
+		sql, args = r.update(ctx, db)
+		action = "update"
+	}
 
-	assignedCount := 0
+	return r.writeSQL(ctx, db, sql, args, nil, func() string { return action })
+}
+
+// writeSQL executes sql with args and updates the record with the returned row. sql must return the table columns
+// followed by a column for each of extraTargets. If the table has an AuditLogTable then sql and the audit log entry
+// are written in a transaction. action is called after sql is executed to get the audit log action. The table's
+// DefaultSaveTimeout is applied and constraint violations are returned as a *ConstraintError.
+func (r *Record) writeSQL(ctx context.Context, db DB, sql string, args []any, extraTargets []any, action func() string) error {
+	// The returned row is scanned into newAttributes so the record is unchanged if the write or the audit log fails.
+	newAttributes := make([]any, len(r.attributes))
+	scanTargets := make([]any, 0, len(newAttributes)+len(extraTargets))
+	for i := range newAttributes {
+		scanTargets = append(scanTargets, &newAttributes[i])
+	}
+	scanTargets = append(scanTargets, extraTargets...)
+
+	if r.table.DefaultSaveTimeout != 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, r.table.DefaultSaveTimeout)
+			defer cancel()
+		}
+	}
+
+	writeRow := func(db DB) error {
+		err := queryRow(ctx, db, r.table.statementSQL(db, sql), args, scanTargets)
+		if err != nil {
+			return wrapConstraintError(err)
+		}
+		return nil
+	}
+
+	var err error
+	if r.table.AuditLogTable == nil {
+		err = writeRow(db)
+	} else {
+		err = inTransaction(ctx, db, func(db DB) error {
+			err := writeRow(db)
+			if err != nil {
+				return err
+			}
+
+			changes := r.table.auditChanges(r.originalAttributes, newAttributes)
+			err = r.table.writeAuditLog(ctx, db, r.table.pkValuesOf(newAttributes), action(), auditActor(ctx), changes)
+			if err != nil {
+				return fmt.Errorf("audit log: %w", err)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	copy(r.attributes, newAttributes)
+	r.originalAttributes = make([]any, len(r.attributes))
+	copy(r.originalAttributes, r.attributes)
+	for i := range r.assigned {
+		r.assigned[i] = false
+	}
+	r.computeAttributes()
+
+	return nil
+}
+
+// ValidateRecord calls the Normalize and Validate hooks for record as Save does but does not save it. Changes made by
+// Normalize are kept. A *ValidationErrors is returned if validation fails and is also available from record.Errors.
+func (t *Table) ValidateRecord(ctx context.Context, db DB, record *Record) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return fmt.Errorf("pgxrecord.Table (%s): ValidateRecord: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	err := record.normalizeAndValidate(ctx, db)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): ValidateRecord: %w", t.quotedQualifiedName, err)
+	}
+
+	return nil
+}
+
+// Reload reloads the record from the database by primary key. Any unsaved changes are discarded.
+func (r *Record) Reload(ctx context.Context, db DB) error {
+	return r.table.Refresh(ctx, db, r)
+}
+
+// UpsertOnConstraint inserts record or, if the insert violates the constraint named constraintName, updates the
+// conflicting row with the assigned attributes of record. Primary key attributes are not updated. The Normalize and
+// Validate hooks are called as in Save. record is updated with the resulting row. The audit log, DefaultSaveTimeout,
+// and constraint errors are handled as in Save. The audit log entry records the action as insert or update depending
+// on whether a row was inserted.
+func (t *Table) UpsertOnConstraint(ctx context.Context, db DB, record *Record, constraintName string) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return fmt.Errorf("pgxrecord.Table (%s): UpsertOnConstraint: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	if t.readOnly {
+		return fmt.Errorf("pgxrecord.Table (%s): UpsertOnConstraint: table is read-only", t.quotedQualifiedName)
+	}
+
+	if constraintName == "" {
+		return fmt.Errorf("pgxrecord.Table (%s): UpsertOnConstraint: constraintName is empty", t.quotedQualifiedName)
+	}
+
+	err := record.normalizeAndValidate(ctx, db)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): UpsertOnConstraint: %w", t.quotedQualifiedName, err)
+	}
+
+	if !record.anyAssigned() {
+		return fmt.Errorf("pgxrecord.Table (%s): UpsertOnConstraint: %w", t.quotedQualifiedName, ErrNoColumns)
+	}
+
+	b := &strings.Builder{}
+	t.writeInsert(b, record.assigned)
+	b.WriteString(" on conflict on constraint ")
+	b.WriteString(pgx.Identifier{constraintName}.Sanitize())
+	b.WriteString(" do update set ")
+
+	updateCount := 0
+	for i, c := range t.Columns {
+		if record.assigned[i] && !c.PrimaryKey {
+			if updateCount > 0 {
+				b.WriteString(", ")
+			}
+			updateCount++
+			b.WriteString(c.quotedName)
+			b.WriteString(" = excluded.")
+			b.WriteString(c.quotedName)
+		}
+	}
+	if updateCount == 0 {
+		return fmt.Errorf("pgxrecord.Table (%s): UpsertOnConstraint: no non-primary key attributes assigned", t.quotedQualifiedName)
+	}
+
+	// xmax is 0 for a newly inserted row. It is used to determine the audit log action.
+	b.WriteByte(' ')
+	b.WriteString(t.ReturningClauseWith("xmax = 0"))
+
+	args := make([]any, 0, len(record.attributes))
+	for i := range record.assigned {
+		if record.assigned[i] {
+			args = append(args, record.attributes[i])
+		}
+	}
+
+	var inserted bool
+	action := func() string {
+		if inserted {
+			return "insert"
+		}
+		return "update"
+	}
+
+	err = record.writeSQL(ctx, db, b.String(), args, []any{&inserted}, action)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): UpsertOnConstraint: %w", t.quotedQualifiedName, err)
+	}
+
+	return nil
+}
+
+// InsertReturning inserts record and appends extraColumns to the returning clause. extraColumns are raw SQL expressions
+// such as "(select count(*) from widgets) as total". The table columns are scanned into record and the extra values are
+// scanned into extraTargets which must have the same length as extraColumns. The Normalize and Validate hooks are
+// called as in Save. The audit log, DefaultSaveTimeout, and constraint errors are handled as in Save. record is
+// returned for convenience.
+func (t *Table) InsertReturning(ctx context.Context, db DB, record *Record, extraColumns []string, extraTargets ...any) (*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	if t.readOnly {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: table is read-only", t.quotedQualifiedName)
+	}
+
+	if record.originalAttributes != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: record is not new", t.quotedQualifiedName)
+	}
+
+	if len(extraColumns) != len(extraTargets) {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: %d extra columns but %d extra targets", t.quotedQualifiedName, len(extraColumns), len(extraTargets))
+	}
+
+	for i, c := range extraColumns {
+		if c == "" {
+			return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: extra column %d is empty", t.quotedQualifiedName, i)
+		}
+	}
+
+	err := record.normalizeAndValidate(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: %w", t.quotedQualifiedName, err)
+	}
+
+	if !record.anyAssigned() {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: %w", t.quotedQualifiedName, ErrNoColumns)
+	}
+
+	b := &strings.Builder{}
+	t.writeInsert(b, record.assigned)
+	b.WriteByte(' ')
+	b.WriteString(t.ReturningClauseWith(extraColumns...))
+
+	args := make([]any, 0, len(record.attributes))
+	for i := range record.assigned {
+		if record.assigned[i] {
+			args = append(args, record.attributes[i])
+		}
+	}
+
+	err = record.writeSQL(ctx, db, b.String(), args, extraTargets, func() string { return "insert" })
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertReturning: %w", t.quotedQualifiedName, err)
+	}
+
+	return record, nil
+}
+
+// InsertDefaultValues inserts a row using only the column defaults and returns it as a persisted record. It is
+// useful for tables where every column has a default. The Normalize and Validate hooks are not called. The audit log,
+// DefaultSaveTimeout, and constraint errors are handled as in Save.
+func (t *Table) InsertDefaultValues(ctx context.Context, db DB) (*Record, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.readOnly {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertDefaultValues: table is read-only", t.quotedQualifiedName)
+	}
+
+	record := t.NewRecord()
+	sql := "insert into " + t.quotedQualifiedName + " default values " + t.returningClause
+	err := record.writeSQL(ctx, db, sql, nil, nil, func() string { return "insert" })
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.Table (%s): InsertDefaultValues: %w", t.quotedQualifiedName, err)
+	}
+
+	return record, nil
+}
+
+// InsertAll inserts records. The Normalize and Validate hooks are called for every record before any are inserted.
+// Records that fail normalization or validation are not inserted. The remaining records are inserted one at a time and
+// updated in place as in Save. If db is a pgx.Tx each insert is run in a savepoint so a failed insert does not abort
+// the transaction.
+//
+// InsertAll returns the records that were inserted and a slice of errors with the same length and order as records.
+// The error for a record that was inserted is nil.
+func (t *Table) InsertAll(ctx context.Context, db DB, records []*Record) ([]*Record, []error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	errs := make([]error, len(records))
+	for i, record := range records {
+		var err error
+		switch {
+		case record.table != t:
+			err = fmt.Errorf("record belongs to table %s", record.table.quotedQualifiedName)
+		case t.readOnly:
+			err = errors.New("table is read-only")
+		case record.originalAttributes != nil:
+			err = errors.New("record is not new")
+		default:
+			err = record.normalizeAndValidate(ctx, db)
+		}
+		if err != nil {
+			errs[i] = fmt.Errorf("pgxrecord.Table (%s): InsertAll: record %d: %w", t.quotedQualifiedName, i, err)
+		}
+	}
+
+	var inserted []*Record
+	for i, record := range records {
+		if errs[i] != nil {
+			continue
+		}
+
+		err := inSavepoint(ctx, db, func(db DB) error { return record.write(ctx, db) })
+		if err != nil {
+			errs[i] = fmt.Errorf("pgxrecord.Table (%s): InsertAll: record %d: %w", t.quotedQualifiedName, i, err)
+			continue
+		}
+
+		inserted = append(inserted, record)
+	}
+
+	return inserted, errs
+}
+
+// InsertSQL returns the SQL and arguments Save would use to insert record. The SQL is not executed and the Normalize
+// and Validate hooks are not called.
+func (t *Table) InsertSQL(record *Record) (string, []any, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return "", nil, fmt.Errorf("pgxrecord.Table (%s): InsertSQL: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	if !record.anyAssigned() {
+		return "", nil, fmt.Errorf("pgxrecord.Table (%s): InsertSQL: %w", t.quotedQualifiedName, ErrNoColumns)
+	}
+
+	sql, args := record.insert(context.Background(), nil)
+	return sql, args, nil
+}
+
+// UpdateSQL returns the SQL and arguments Save would use to update record. The SQL is not executed and the Normalize
+// and Validate hooks are not called.
+func (t *Table) UpdateSQL(record *Record) (string, []any, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return "", nil, fmt.Errorf("pgxrecord.Table (%s): UpdateSQL: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	if t.noPrimaryKey {
+		return "", nil, fmt.Errorf("pgxrecord.Table (%s): UpdateSQL: %w", t.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	if !record.anyAssigned() {
+		return "", nil, fmt.Errorf("pgxrecord.Table (%s): UpdateSQL: %w", t.quotedQualifiedName, ErrNoColumns)
+	}
+
+	sql, args := record.update(context.Background(), nil)
+	return sql, args, nil
+}
+
+// DeleteSQL returns the SQL and arguments to delete record by primary key. The SQL is not executed.
+func (t *Table) DeleteSQL(record *Record) (string, []any, error) {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if record.table != t {
+		return "", nil, fmt.Errorf("pgxrecord.Table (%s): DeleteSQL: record belongs to table %s", t.quotedQualifiedName, record.table.quotedQualifiedName)
+	}
+
+	if t.noPrimaryKey {
+		return "", nil, fmt.Errorf("pgxrecord.Table (%s): DeleteSQL: %w", t.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	return "delete from " + t.quotedQualifiedName + " " + t.pkWhereClause, t.PKValues(record), nil
+}
+
+// anyAssigned returns true if any attribute has been assigned.
+func (r *Record) anyAssigned() bool {
+	for _, a := range r.assigned {
+		if a {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAndValidate calls the table Normalize and Validate hooks. If validation fails with a *ValidationErrors it
+// is stored on r.
+func (r *Record) normalizeAndValidate(ctx context.Context, db DB) error {
+	r.validationErrors = nil
+
+	if fn := r.table.Normalize; fn != nil {
+		err := fn(ctx, db, r.table, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	if fn := r.table.Validate; fn != nil {
+		err := fn(ctx, db, r.table, r)
+		if err != nil {
+			var ve *ValidationErrors
+			if errors.As(err, &ve) {
+				r.validationErrors = ve
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Record) insert(ctx context.Context, db DB) (string, []any) {
+	args := make([]any, 0, len(r.attributes))
+	for i := range r.assigned {
+		if r.assigned[i] {
+			args = append(args, r.attributes[i])
+		}
+	}
+
+	return r.table.buildInsertSQL(r.assigned), args
+}
+
+func (r *Record) update(ctx context.Context, db DB) (string, []any) {
+	args := make([]any, 0, len(r.attributes))
+	args = append(args, r.table.PKValues(r)...)
 	for i := range r.assigned {
 		if r.assigned[i] {
+			args = append(args, r.attributes[i])
+		}
+	}
+
+	return r.table.buildUpdateSQL(r.assigned), args
+}
+
+// buildInsertSQL builds the SQL to insert the assigned columns. The arguments are the assigned column values in column
+// order.
+func (t *Table) buildInsertSQL(assigned []bool) string {
+	b := &strings.Builder{}
+	t.writeInsert(b, assigned)
+	b.WriteByte(' ')
+	b.WriteString(t.returningClause)
+
+	return b.String()
+}
+
+// writeInsert writes the insert statement without a returning clause for the assigned columns to b.
+func (t *Table) writeInsert(b *strings.Builder, assigned []bool) {
+	b.WriteString("insert into ")
+	b.WriteString(t.quotedQualifiedName)
+	b.WriteString(" (")
+
+	assignedCount := 0
+	for i := range assigned {
+		if assigned[i] {
 			if assignedCount > 0 {
 				b.WriteString(", ")
 			}
 			assignedCount++
-			b.WriteString(r.table.Columns[i].quotedName)
+			b.WriteString(t.Columns[i].quotedName)
 		}
 	}
 
 	b.WriteString(") values (")
-	args := make([]any, assignedCount)
 	assignedCount = 0
-	for i := range r.assigned {
-		if r.assigned[i] {
+	for i := range assigned {
+		if assigned[i] {
 			if assignedCount > 0 {
 				b.WriteString(", ")
 			}
-			args[assignedCount] = r.attributes[i]
 			assignedCount++
 			b.WriteByte('$')
 			b.WriteString(strconv.FormatInt(int64(assignedCount), 10))
 		}
 	}
 
-	b.WriteString(") ")
-	b.WriteString(r.table.returningClause)
-
-	return b.String(), args
+	b.WriteByte(')')
 }
 
-func (r *Record) update(ctx context.Context, db DB) (string, []any) {
+// buildUpdateSQL builds the SQL to update the assigned columns. The arguments are the primary key values followed by
+// the assigned column values in column order.
+func (t *Table) buildUpdateSQL(assigned []bool) string {
 	b := &strings.Builder{}
 	b.WriteString("update ")
-	b.WriteString(r.table.quotedQualifiedName)
+	b.WriteString(t.quotedQualifiedName)
 	b.WriteString(" set ")
 
-	args := make([]any, 0, len(r.attributes))
-	for _, pkIdx := range r.table.pkIndexes {
-		args = append(args, r.attributes[pkIdx])
-	}
-
-	assignedCount := 0
-	for i := range r.assigned {
-		if r.assigned[i] {
-			if assignedCount > 0 {
+	argCount := len(t.pkIndexes)
+	for i := range assigned {
+		if assigned[i] {
+			if argCount > len(t.pkIndexes) {
 				b.WriteString(", ")
 			}
-			args = append(args, r.attributes[i])
-			assignedCount++
-			b.WriteString(r.table.Columns[i].quotedName)
+			argCount++
+			b.WriteString(t.Columns[i].quotedName)
 			b.WriteString(" = $")
-			b.WriteString(strconv.FormatInt(int64(len(args)), 10))
+			b.WriteString(strconv.FormatInt(int64(argCount), 10))
 		}
 	}
 
 	b.WriteByte(' ')
-	b.WriteString(r.table.pkWhereClause)
+	b.WriteString(t.pkWhereClause)
 
 	b.WriteByte(' ')
-	b.WriteString(r.table.returningClause)
+	b.WriteString(t.returningClause)
 
-	return b.String(), args
+	return b.String()
 }
 
+// Errors returns the validation errors from the last call to Save or Table.ValidateRecord. It returns nil if the last
+// call did not fail validation.
 func (r *Record) Errors() *ValidationErrors {
-	return r.table.validationErrors
+	return r.validationErrors
+}
+
+// HasErrors returns true if the last call to Save or Table.ValidateRecord failed validation.
+func (r *Record) HasErrors() bool {
+	return r.validationErrors.Len() > 0
 }
 
 // queryRow builds QueryRow-like functionality on top of DB. This allows pgxutil to have the convenience of QueryRow
@@ -474,8 +2323,15 @@ func queryRow(ctx context.Context, db DB, sql string, args []any, scanTargets []
 	defer rows.Close()
 
 	if rows.Next() {
-		rows.Scan(scanTargets...)
+		err = rows.Scan(scanTargets...)
+		if err != nil {
+			return err
+		}
 	} else {
+		err = rows.Err()
+		if err != nil {
+			return err
+		}
 		return pgx.ErrNoRows
 	}
 
@@ -490,3 +2346,14 @@ func queryRow(ctx context.Context, db DB, sql string, args []any, scanTargets []
 
 	return nil
 }
+
+// exec builds Exec-like functionality on top of DB.
+func exec(ctx context.Context, db DB, sql string, args []any) error {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	rows.Close()
+
+	return rows.Err()
+}
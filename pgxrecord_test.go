@@ -2,11 +2,17 @@ package pgxrecord_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxtest"
 	"github.com/jackc/pgxrecord"
@@ -25,9 +31,1639 @@ func init() {
 	}
 }
 
+func TestColumnNullableAndIsRequired(t *testing.T) {
+	t.Parallel()
+
+	pk := &pgxrecord.Column{Name: "id", NotNull: true, PrimaryKey: true}
+	require.False(t, pk.Nullable())
+	require.False(t, pk.IsRequired())
+
+	notNull := &pgxrecord.Column{Name: "name", NotNull: true}
+	require.False(t, notNull.Nullable())
+	require.True(t, notNull.IsRequired())
+
+	nullable := &pgxrecord.Column{Name: "age"}
+	require.True(t, nullable.Nullable())
+	require.False(t, nullable.IsRequired())
+}
+
 func TestTableLoadAllColumns(t *testing.T) {
 	t.Parallel()
 
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int,
+	created_at timestamptz not null default now()
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		require.Len(t, table.Columns, 4)
+		expectedColumns := []pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true, HasDefault: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false, HasDefault: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false, HasDefault: false},
+			{Name: "created_at", OID: pgtype.TimestamptzOID, NotNull: true, PrimaryKey: false, HasDefault: true},
+		}
+		for i := range expectedColumns {
+			assert.Equalf(t, expectedColumns[i].Name, table.Columns[i].Name, "Column %d name", i+1)
+			assert.Equalf(t, expectedColumns[i].OID, table.Columns[i].OID, "Column %d OID", i+1)
+			assert.Equalf(t, expectedColumns[i].NotNull, table.Columns[i].NotNull, "Column %d not null", i+1)
+			assert.Equalf(t, expectedColumns[i].PrimaryKey, table.Columns[i].PrimaryKey, "Column %d primary key", i+1)
+			assert.Equalf(t, expectedColumns[i].HasDefault, table.Columns[i].HasDefault, "Column %d has default", i+1)
+		}
+	})
+}
+
+func TestParseDSN(t *testing.T) {
+	t.Parallel()
+
+	config, err := pgxrecord.ParseDSN("postgres://jack@db.example.com:5433/app")
+	require.NoError(t, err)
+	require.Equal(t, "db.example.com", config.Host)
+	require.EqualValues(t, 5433, config.Port)
+	require.Equal(t, "app", config.Database)
+	require.Equal(t, "jack", config.User)
+
+	config, err = pgxrecord.ParseDSN("host=localhost port=5434 dbname=other")
+	require.NoError(t, err)
+	require.Equal(t, "localhost", config.Host)
+	require.EqualValues(t, 5434, config.Port)
+	require.Equal(t, "other", config.Database)
+
+	_, err = pgxrecord.ParseDSN("postgres://invalid:port:port")
+	require.Error(t, err)
+}
+
+func TestNewTable(t *testing.T) {
+	t.Parallel()
+
+	table := pgxrecord.NewTable(pgx.Identifier{"widgets"}, []*pgxrecord.Column{
+		{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+		{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+	})
+	require.Equal(t, `select "widgets"."id", "widgets"."name" from "widgets"`, table.MustFinalize().SelectQuery())
+
+	require.Panics(t, func() { pgxrecord.NewTable(nil, nil) })
+	require.Panics(t, func() { pgxrecord.NewTable(pgx.Identifier{}, nil) })
+}
+
+func TestTableDropColumn(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "photo", OID: pgtype.ByteaOID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	err := table.DropColumn("photo")
+	require.NoError(t, err)
+
+	err = table.DropColumn("missing")
+	require.ErrorContains(t, err, "missing")
+
+	require.Equal(t, `select "t"."id", "t"."name" from "t"`, table.SelectQuery())
+
+	err = table.DropColumn("name")
+	require.ErrorContains(t, err, "finalized")
+}
+
+func TestTableMustFinalizeMisconfigured(t *testing.T) {
+	t.Parallel()
+
+	require.PanicsWithError(t, "pgxrecord.Table: name is empty", func() {
+		(&pgxrecord.Table{}).MustFinalize()
+	})
+
+	require.PanicsWithError(t, `pgxrecord.Table ("widgets"): duplicate column "name"`, func() {
+		(&pgxrecord.Table{
+			Name: pgx.Identifier{"widgets"},
+			Columns: []*pgxrecord.Column{
+				{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+				{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+				{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			},
+		}).MustFinalize()
+	})
+
+	require.PanicsWithError(t, `pgxrecord.Table ("widgets"): column 1 name is empty`, func() {
+		(&pgxrecord.Table{
+			Name: pgx.Identifier{"widgets"},
+			Columns: []*pgxrecord.Column{
+				{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+				{Name: "", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			},
+		}).MustFinalize()
+	})
+}
+
+func TestTableWithColumns(t *testing.T) {
+	t.Parallel()
+
+	table := (&pgxrecord.Table{Name: pgx.Identifier{"widgets"}}).WithColumns([]*pgxrecord.Column{
+		{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+		{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+	}).MustFinalize()
+
+	require.Equal(t, `select "widgets"."id", "widgets"."name" from "widgets"`, table.SelectQuery())
+
+	record := table.NewRecord()
+	record.Set("name", "foo")
+	require.Equal(t, map[string]any{"id": nil, "name": "foo"}, record.Attributes())
+
+	require.Panics(t, func() { table.WithColumns(nil) })
+}
+
+// blockingRows is a pgx.Rows that blocks until ctx is done.
+type blockingRows struct {
+	pgx.Rows
+	ctx context.Context
+}
+
+func (rows *blockingRows) Next() bool {
+	<-rows.ctx.Done()
+	return false
+}
+
+func (rows *blockingRows) Err() error { return rows.ctx.Err() }
+func (rows *blockingRows) Close()     {}
+
+// blockingDB is a DB whose queries block until the context is done.
+type blockingDB struct{}
+
+func (db *blockingDB) Query(ctx context.Context, sql string, optionsAndArgs ...any) (pgx.Rows, error) {
+	return &blockingRows{ctx: ctx}, nil
+}
+
+func TestTableLoadAllColumnsLoadTimeout(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name:        pgx.Identifier{"t"},
+		LoadTimeout: 10 * time.Millisecond,
+	}
+
+	err := table.LoadAllColumns(context.Background(), &blockingDB{})
+	require.ErrorContains(t, err, context.DeadlineExceeded.Error())
+}
+
+func TestTableLoadAllColumnsTwice(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		err = table.LoadAllColumns(ctx, conn)
+		require.ErrorContains(t, err, "columns already loaded")
+
+		err = table.LoadColumnsSubset(ctx, conn, []string{"id"})
+		require.ErrorContains(t, err, "columns already loaded")
+		require.Len(t, table.Columns, 3)
+	})
+}
+
+func TestTableLoadColumnsSubset(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int,
+	bio text
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadColumnsSubset(ctx, conn, []string{"name", "id"})
+		require.NoError(t, err)
+
+		require.Len(t, table.Columns, 2)
+		require.Equal(t, "id", table.Columns[0].Name)
+		require.Equal(t, "name", table.Columns[1].Name)
+		require.Equal(t, `select "t"."id", "t"."name" from "t"`, table.SelectQuery())
+
+		record := table.NewRecord()
+		record.Set("name", "John")
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John"}, record.Attributes())
+
+		otherTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = otherTable.LoadColumnsSubset(ctx, conn, []string{"name", "missing"})
+		require.ErrorContains(t, err, "missing")
+	})
+}
+
+func TestTableLoadColumnsSubsetEmpty(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+	}
+	err := table.LoadColumnsSubset(context.Background(), &errDB{}, nil)
+	require.ErrorContains(t, err, "columnNames is empty")
+
+	err = table.LoadColumnsSubset(context.Background(), &errDB{}, []string{})
+	require.ErrorContains(t, err, "columnNames is empty")
+}
+
+func TestTableLoadAllColumnsIgnoresPartialUniqueIndex(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	email text not null,
+	deleted_at timestamptz
+);
+create unique index on t (email) where deleted_at is null;`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		require.Len(t, table.Columns, 3)
+		require.True(t, table.Columns[0].PrimaryKey)
+		require.False(t, table.Columns[1].PrimaryKey)
+		require.False(t, table.Columns[2].PrimaryKey)
+	})
+}
+
+func TestTableColumnsByOID(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, []*pgxrecord.Column{table.Columns[0], table.Columns[2]}, table.ColumnsByOID(pgtype.Int4OID))
+	require.Equal(t, []*pgxrecord.Column{table.Columns[1]}, table.ColumnsByOID(pgtype.TextOID))
+	require.Nil(t, table.ColumnsByOID(pgtype.BoolOID))
+}
+
+func TestTableWithSchema(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+	require.Equal(t, `select "t"."id", "t"."name" from "t"`, table.SelectQuery())
+
+	tenantTable := table.WithSchema("tenant1")
+	require.Equal(t, pgx.Identifier{"tenant1", "t"}, tenantTable.Name)
+	require.Equal(t, `select "t"."id", "t"."name" from "tenant1"."t"`, tenantTable.SelectQuery())
+
+	otherTenantTable := tenantTable.WithSchema("tenant2")
+	require.Equal(t, `select "t"."id", "t"."name" from "tenant2"."t"`, otherTenantTable.SelectQuery())
+}
+
+func TestTableColumnOrder(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, []string{"id", "name", "age"}, table.ColumnOrder())
+}
+
+func TestColumnQuotedName(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "Full Name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Panics(t, func() { table.Columns[1].QuotedName() })
+
+	table.MustFinalize()
+	require.Equal(t, `"id"`, table.Columns[0].QuotedName())
+	require.Equal(t, `"Full Name"`, table.Columns[1].QuotedName())
+}
+
+func TestTablePrimaryKeyColumnNames(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "tenant_id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+		},
+	}
+	require.Equal(t, []string{"tenant_id", "id"}, table.PrimaryKeyColumnNames())
+
+	noPKTable := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+	require.Nil(t, noPKTable.PrimaryKeyColumnNames())
+}
+
+func TestTableColumnFilters(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, []*pgxrecord.Column{table.Columns[0], table.Columns[1]}, table.NotNullColumns())
+	require.Equal(t, []*pgxrecord.Column{table.Columns[0]}, table.PrimaryKeyColumns())
+	require.Equal(t, []*pgxrecord.Column{table.Columns[1], table.Columns[2]}, table.NonPrimaryKeyColumns())
+}
+
+func TestTableSelectQuery(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		require.Equal(t, `select "t"."id", "t"."name", "t"."age" from "t"`, table.SelectQuery())
+		require.Equal(t, `select "t"."id", "t"."name", "t"."age" from "t" where "id" = $1`, table.SelectByPKQuery())
+	})
+}
+
+func TestTableSelectQueryWhere(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		require.Equal(t, `select "t"."id", "t"."name", "t"."age" from "t" where age > $1`, table.SelectQueryWhere("age > $1"))
+		require.Equal(t, `select "t"."id", "t"."name", "t"."age" from "t" order by name`, table.SelectQueryOrderBy("name"))
+		require.Panics(t, func() { table.SelectQueryWhere("") })
+		require.Panics(t, func() { table.SelectQueryOrderBy("") })
+	})
+}
+
+func TestTableSelectQueryWithAlias(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"public", "t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, `select "t2"."id", "t2"."name" from "public"."t" as "t2"`, table.SelectQueryWithAlias("t2"))
+}
+
+func TestTableSelectJoin(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+create temporary table u (
+	t_id int not null,
+	role text not null
+);
+insert into t (name, age) values ('John', 42), ('Jane', 40), ('Bill', 30);
+insert into u (t_id, role) values (1, 'admin'), (3, 'admin'), (2, 'user');`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		records, err := table.SelectJoin(ctx, conn, `join u on u.t_id = t.id where u.role = $1 order by t.id`, "admin")
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "John", records[0].Get("name"))
+		require.Equal(t, "Bill", records[1].Get("name"))
+
+		records, err = table.SelectJoin(ctx, conn, `LEFT JOIN u on u.t_id = t.id and u.role = 'user' order by t.id`)
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+
+		_, err = table.SelectJoin(ctx, conn, `; drop table u`)
+		require.Error(t, err)
+
+		_, err = table.SelectJoin(ctx, conn, `left outer join u on u.t_id = t.id`)
+		require.Error(t, err)
+	})
+}
+
+func TestTableSelectDistinct(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('John', 42), ('Jane', 40), ('Bill', 30), ('Bill', 42);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		records, err := table.SelectDistinct(ctx, conn, []string{"name"}, "")
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		require.Equal(t, "Bill", records[0].Get("name"))
+		require.Equal(t, "Jane", records[1].Get("name"))
+		require.Equal(t, "John", records[2].Get("name"))
+
+		records, err = table.SelectDistinct(ctx, conn, []string{"age", "name"}, "age > $1", 35)
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		require.Equal(t, "Jane", records[0].Get("name"))
+		require.Equal(t, "Bill", records[1].Get("name"))
+		require.Equal(t, "John", records[2].Get("name"))
+
+		_, err = table.SelectDistinct(ctx, conn, []string{"missing"}, "")
+		require.ErrorContains(t, err, "missing")
+	})
+}
+
+func TestTableReturningClause(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, `returning "id", "name"`, table.ReturningClause())
+}
+
+func TestTableReturningClauseWith(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, `returning "id", "name"`, table.ReturningClauseWith())
+	require.Equal(t, `returning "id", "name", xmax = 0 as inserted, now()`, table.ReturningClauseWith("xmax = 0 as inserted", "now()"))
+	require.Panics(t, func() { table.ReturningClauseWith("now()", "") })
+}
+
+func TestTablePKWhereClause(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "tenant_id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+	require.Equal(t, `where "tenant_id" = $1 and "id" = $2`, table.PKWhereClause())
+
+	noPKTable := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+	require.Equal(t, "", noPKTable.PKWhereClause())
+}
+
+func TestTableFindAllOrdered(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('Jane', 40), ('Bill', 42), ('Mary', 30);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		names := func(records []*pgxrecord.Record) []any {
+			names := make([]any, len(records))
+			for i, r := range records {
+				names[i] = r.Get("name")
+			}
+			return names
+		}
+
+		records, err := table.FindAllOrdered(ctx, conn, []pgxrecord.SortColumn{{Name: "age", Desc: true}, {Name: "name"}}, "")
+		require.NoError(t, err)
+		require.Equal(t, []any{"Bill", "John", "Jane", "Mary"}, names(records))
+
+		records, err = table.FindAllOrdered(ctx, conn, []pgxrecord.SortColumn{{Name: "id", Desc: true}}, "age > $1", 35)
+		require.NoError(t, err)
+		require.Equal(t, []any{"Bill", "Jane", "John"}, names(records))
+
+		_, err = table.FindAllOrdered(ctx, conn, []pgxrecord.SortColumn{{Name: "missing"}}, "")
+		require.ErrorContains(t, err, "missing")
+	})
+}
+
+func TestTableSelectQueryFullyQualified(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"public", "t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, `select "public"."t"."id", "public"."t"."name" from "public"."t"`, table.SelectQueryFullyQualified())
+}
+
+func TestTableInsertQueryAndUpdateQuery(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, `insert into "t" ("id", "name") values ($1, $2) returning "id", "name"`, table.InsertQuery())
+	require.Equal(t, `update "t" set "id" = $2, "name" = $3 where "id" = $1 returning "id", "name"`, table.UpdateQuery())
+}
+
+func TestTableNewRecord(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+		require.Equal(t, map[string]any{"id": nil, "name": nil, "age": nil}, record.Attributes())
+
+		err = record.SetAttributesStrict(map[string]any{"name": "John", "age": 42})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": nil, "name": "John", "age": 42}, record.Attributes())
+	})
+}
+
+func TestTableFindByPK(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		var id int32
+		err = conn.QueryRow(ctx, `insert into t (name, age) values ('John', 42) returning id`).Scan(&id)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record, err := table.FindByPK(ctx, conn, id)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+
+		_, err = table.FindByPK(ctx, conn, id+1)
+		require.True(t, pgxrecord.IsNotFound(err))
+		require.ErrorIs(t, err, pgx.ErrNoRows)
+		require.ErrorIs(t, err, pgxrecord.ErrNotFound)
+		var nfe *pgxrecord.NotFoundError
+		require.ErrorAs(t, err, &nfe)
+		require.Equal(t, `"t"`, nfe.Table)
+		require.Equal(t, []any{id + 1}, nfe.PK)
+	})
+}
+
+func TestTableSelectByPKQueryNoPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, "", table.SelectByPKQuery())
+	require.Equal(t, "", table.SelectForUpdateQuery())
+}
+
+func TestTableSelectForUpdateQuery(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Equal(t, `select "t"."id", "t"."name" from "t" where "id" = $1 for update`, table.SelectForUpdateQuery())
+}
+
+func TestTableLock(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		err = pgxrecord.WithTransaction(ctx, conn, func(tx pgx.Tx) error {
+			record, err := table.Lock(ctx, tx, 1)
+			require.NoError(t, err)
+			require.Equal(t, "John", record.Get("name"))
+
+			var lockCount int
+			err = tx.QueryRow(ctx, `select count(*) from pg_locks where relation = 't'::regclass and mode = 'RowShareLock' and pid = pg_backend_pid()`).Scan(&lockCount)
+			require.NoError(t, err)
+			require.Equal(t, 1, lockCount)
+
+			_, err = table.Lock(ctx, tx, 2)
+			require.True(t, pgxrecord.IsNotFound(err))
+
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestTableFindByUnique(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int,
+	unique (name, age)
+);
+insert into t (name, age) values ('John', 42), ('John', 40), ('Jane', 40);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record, err := table.FindByUnique(ctx, conn, []string{"name", "age"}, []any{"John", 40})
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(2), "name": "John", "age": int32(40)}, record.Attributes())
+
+		record, err = table.FindByUnique(ctx, conn, []string{"age", "name"}, []any{42, "John"})
+		require.NoError(t, err)
+		require.Equal(t, int32(1), record.Get("id"))
+
+		_, err = table.FindByUnique(ctx, conn, []string{"name", "age"}, []any{"Bill", 40})
+		require.True(t, pgxrecord.IsNotFound(err))
+		require.ErrorContains(t, err, `"t" with [name age] = [Bill 40] not found`)
+
+		_, err = table.FindByUnique(ctx, conn, []string{"name"}, []any{"John"})
+		require.ErrorContains(t, err, "too many rows")
+
+		_, err = table.FindByUnique(ctx, conn, []string{"missing"}, []any{"John"})
+		require.ErrorContains(t, err, "missing")
+
+		_, err = table.FindByUnique(ctx, conn, []string{"name"}, []any{"John", 42})
+		require.Error(t, err)
+	})
+}
+
+func TestTableFindByPKBatch(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	a int,
+	b text,
+	name text not null,
+	primary key (a, b)
+);
+insert into t (a, b, name) values (1, 'x', 'John'), (1, 'y', 'Bill'), (2, 'x', 'George');`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		records, err := table.FindByPKBatch(ctx, conn, [][]any{{2, "x"}, {3, "z"}, {1, "x"}})
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		require.Equal(t, "George", records[0].Get("name"))
+		require.Nil(t, records[1])
+		require.Equal(t, "John", records[2].Get("name"))
+
+		records, err = table.FindByPKBatch(ctx, conn, nil)
+		require.NoError(t, err)
+		require.Empty(t, records)
+
+		_, err = table.FindByPKBatch(ctx, conn, [][]any{{1}})
+		require.Error(t, err)
+	})
+}
+
+func TestFindByPKTyped(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		var id int32
+		err = conn.QueryRow(ctx, `insert into t (name, age) values ('John', 42) returning id`).Scan(&id)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		type person struct {
+			ID   int32
+			Name string
+			Age  *int32
+		}
+
+		p, err := pgxrecord.FindByPKTyped[person](ctx, conn, table, id)
+		require.NoError(t, err)
+		require.Equal(t, id, p.ID)
+		require.Equal(t, "John", p.Name)
+		require.EqualValues(t, 42, *p.Age)
+
+		_, err = pgxrecord.FindByPKTyped[person](ctx, conn, table, id+1)
+		require.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+}
+
+func TestTableFindOrCreate(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null unique,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		tx, err := conn.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+
+		record, created, err := table.FindOrCreate(ctx, tx, map[string]any{"name": "John"}, map[string]any{"age": 42})
+		require.NoError(t, err)
+		require.True(t, created)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+
+		record, created, err = table.FindOrCreate(ctx, tx, map[string]any{"name": "John"}, map[string]any{"age": 50})
+		require.NoError(t, err)
+		require.False(t, created)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+
+		_, _, err = table.FindOrCreate(ctx, tx, map[string]any{"missing": "John"}, nil)
+		require.Error(t, err)
+
+		_, _, err = table.FindOrCreate(ctx, tx, nil, map[string]any{"name": "Bill"})
+		require.ErrorContains(t, err, "searchAttrs is empty")
+	})
+}
+
+func TestTableNoPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('Bill', 50);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		_, err = table.FindByPK(ctx, conn, 1)
+		require.ErrorContains(t, err, "table has no primary key")
+
+		rows, _ := conn.Query(ctx, table.SelectQueryWhere("name = 'John'"))
+		record, err := pgx.CollectOneRow(rows, table.RowToRecord)
+		require.NoError(t, err)
+
+		record.Set("age", 43)
+		err = record.Save(ctx, conn)
+		require.ErrorContains(t, err, "table has no primary key")
+
+		var count int
+		err = conn.QueryRow(ctx, `select count(*) from t where age = 43`).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 0, count)
+
+		// Insert still works.
+		record = table.NewRecord()
+		record.SetAttributes(map[string]any{"name": "George", "age": 30})
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+	})
+}
+
+func TestTableRefresh(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		var id int32
+		err = conn.QueryRow(ctx, `insert into t (name, age) values ('John', 42) returning id`).Scan(&id)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record, err := table.FindByPK(ctx, conn, id)
+		require.NoError(t, err)
+
+		_, err = conn.Exec(ctx, `update t set name = 'Bill' where id = $1`, id)
+		require.NoError(t, err)
+
+		record.Set("age", 50)
+		err = table.Refresh(ctx, conn, record)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "Bill", "age": int32(42)}, record.Attributes())
+
+		err = table.Refresh(ctx, conn, table.NewRecord())
+		require.Error(t, err)
+
+		otherTable := &pgxrecord.Table{
+			Name:    pgx.Identifier{"t"},
+			Columns: table.Columns,
+		}
+		err = otherTable.Refresh(ctx, conn, record)
+		require.Error(t, err)
+	})
+}
+
+// scanErrRows is a pgx.Rows with a single row that fails to scan.
+type scanErrRows struct {
+	pgx.Rows
+	err  error
+	done bool
+}
+
+func TestTableRefreshZeroPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	db := &recordingDB{}
+	for _, pk := range []any{nil, int32(0), pgtype.Int4{}} {
+		record := table.NewRecord()
+		record.Set("id", pk)
+		err := table.Refresh(context.Background(), db, record)
+		require.ErrorContains(t, err, `primary key "id" is null or zero`)
+		require.Empty(t, db.sql)
+	}
+}
+
+func (rows *scanErrRows) Next() bool {
+	if rows.done {
+		return false
+	}
+	rows.done = true
+	return true
+}
+
+func (rows *scanErrRows) Scan(dest ...any) error { return rows.err }
+func (rows *scanErrRows) Err() error             { return nil }
+func (rows *scanErrRows) Close()                 {}
+
+type scanErrDB struct {
+	err error
+}
+
+func (db *scanErrDB) Query(ctx context.Context, sql string, optionsAndArgs ...any) (pgx.Rows, error) {
+	return &scanErrRows{err: db.err}, nil
+}
+
+func TestTableRefreshPropagatesScanError(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	scanErr := errors.New("cannot scan")
+	record := table.NewRecord()
+	record.Set("id", int32(1))
+
+	err := table.Refresh(context.Background(), &scanErrDB{err: scanErr}, record)
+	require.ErrorIs(t, err, scanErr)
+}
+
+func TestTableRefreshMaterializedView(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		// Materialized views cannot be temporary or use temporary tables so create them in a transaction that is rolled
+		// back.
+		tx, err := conn.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+
+		_, err = tx.Exec(ctx, `create table pgxrecord_mv_source (
+	id int primary key generated by default as identity,
+	name text not null
+)`)
+		require.NoError(t, err)
+
+		_, err = tx.Exec(ctx, `create materialized view pgxrecord_mv as select id, name from pgxrecord_mv_source`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name:               pgx.Identifier{"pgxrecord_mv"},
+			IsMaterializedView: true,
+		}
+		err = table.LoadAllColumns(ctx, tx)
+		require.NoError(t, err)
+
+		_, err = tx.Exec(ctx, `insert into pgxrecord_mv_source (name) values ('John')`)
+		require.NoError(t, err)
+
+		err = table.RefreshMaterializedView(ctx, tx, false)
+		require.NoError(t, err)
+
+		var count int
+		err = tx.QueryRow(ctx, `select count(*) from pgxrecord_mv`).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		record := table.NewRecord()
+		record.Set("name", "Bill")
+		err = record.Save(ctx, tx)
+		require.ErrorContains(t, err, "read-only")
+	})
+}
+
+func TestTableLoadViewColumns(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42);
+create temporary view v as select id, name from t;`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"v"},
+		}
+		err = table.LoadViewColumns(ctx, conn)
+		require.NoError(t, err)
+		require.True(t, table.IsReadOnly)
+		require.Equal(t, []string{"id", "name"}, table.ColumnOrder())
+		require.Nil(t, table.PrimaryKeyColumnNames())
+
+		records, err := table.Query().Execute(ctx, conn)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "John", records[0].Get("name"))
+
+		record := table.NewRecord()
+		record.Set("name", "Bill")
+		err = record.Save(ctx, conn)
+		require.ErrorContains(t, err, "read-only")
+	})
+}
+
+func TestTablePKValues(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "a", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "b", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+		},
+	}
+
+	record := table.NewRecord()
+	record.SetAttributes(map[string]any{"a": int32(1), "name": "John", "b": int32(2)})
+	require.Equal(t, []any{int32(1), int32(2)}, table.PKValues(record))
+}
+
+func TestRecordPool(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('Bill', 50);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		pool := pgxrecord.NewRecordPool(table)
+
+		rows, _ := conn.Query(ctx, table.SelectQueryOrderBy("id"))
+		records, err := pgx.CollectRows(rows, pool.RowToRecord)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, records[0].Attributes())
+		require.Equal(t, map[string]any{"id": int32(2), "name": "Bill", "age": int32(50)}, records[1].Attributes())
+
+		for _, record := range records {
+			pool.Put(record)
+		}
+
+		record := pool.Get()
+		require.Equal(t, map[string]any{"id": nil, "name": nil, "age": nil}, record.Attributes())
+
+		// A record from the pool is saved as a new record.
+		record.Set("name", "George")
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(3), "name": "George", "age": nil}, record.Attributes())
+	})
+}
+
+func TestTablePrepareStatements(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		err = table.PrepareStatements(ctx, conn)
+		require.NoError(t, err)
+
+		err = table.PrepareStatements(ctx, conn)
+		require.Error(t, err)
+
+		record := table.NewRecord()
+		record.SetAttributes(map[string]any{"id": 1, "name": "John", "age": 42})
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		record.SetAttributes(map[string]any{"id": 1, "name": "Bill", "age": 43})
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		record, err = table.FindByPK(ctx, conn, 1)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "Bill", "age": int32(43)}, record.Attributes())
+
+		err = table.UnprepareStatements(ctx, conn)
+		require.NoError(t, err)
+
+		record, err = table.FindByPK(ctx, conn, 1)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "Bill", "age": int32(43)}, record.Attributes())
+	})
+}
+
+func TestTablePrepareStatementsGeneratedColumns(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated always as identity,
+	name text not null,
+	age int,
+	name_length int generated always as (length(name)) stored
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		err = table.PrepareStatements(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+		record.SetAttributes(map[string]any{"name": "John", "age": 42})
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42), "name_length": int32(4)}, record.Attributes())
+
+		record.SetAttributes(map[string]any{"name": "Bill", "age": 43})
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		record, err = table.FindByPK(ctx, conn, 1)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "Bill", "age": int32(43), "name_length": int32(4)}, record.Attributes())
+
+		err = table.UnprepareStatements(ctx, conn)
+		require.NoError(t, err)
+	})
+}
+
+func TestTablePrepareStatementsSameRelation(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		subsetTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = subsetTable.LoadColumnsSubset(ctx, conn, []string{"id", "name"})
+		require.NoError(t, err)
+
+		err = table.PrepareStatements(ctx, conn)
+		require.NoError(t, err)
+
+		err = subsetTable.PrepareStatements(ctx, conn)
+		require.NoError(t, err)
+
+		// A table whose statements fail to prepare must not deallocate the statements of other tables.
+		brokenTable := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+			Columns: []*pgxrecord.Column{
+				{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+				{Name: "missing", OID: pgtype.TextOID},
+			},
+		}
+		err = brokenTable.PrepareStatements(ctx, conn)
+		require.Error(t, err)
+
+		record, err := table.FindByPK(ctx, conn, 1)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+
+		record, err = subsetTable.FindByPK(ctx, conn, 1)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John"}, record.Attributes())
+
+		err = subsetTable.UnprepareStatements(ctx, conn)
+		require.NoError(t, err)
+
+		record, err = table.FindByPK(ctx, conn, 1)
+		require.NoError(t, err)
+		require.Equal(t, "John", record.Get("name"))
+
+		err = table.UnprepareStatements(ctx, conn)
+		require.NoError(t, err)
+	})
+}
+
+func TestTableAddComputedColumn(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	first_name text not null,
+	last_name text not null
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		table.AddComputedColumn("full_name", func(r *pgxrecord.Record) any {
+			return fmt.Sprintf("%v %v", r.Get("first_name"), r.Get("last_name"))
+		})
+		require.Panics(t, func() { table.AddComputedColumn("first_name", func(r *pgxrecord.Record) any { return nil }) })
+
+		record := table.NewRecord()
+		record.SetAttributes(map[string]any{"first_name": "John", "last_name": "Smith", "full_name": "ignored"})
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, "John Smith", record.Get("full_name"))
+
+		record, err = table.FindByPK(ctx, conn, record.Get("id"))
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "first_name": "John", "last_name": "Smith", "full_name": "John Smith"}, record.Attributes())
+
+		require.Panics(t, func() { table.AddComputedColumn("other", func(r *pgxrecord.Record) any { return nil }) })
+	})
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('Bill', 50);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		rows, _ := conn.Query(ctx, table.SelectQueryOrderBy("id"))
+		names, err := pgxrecord.Collect(rows, table, func(r *pgxrecord.Record) string {
+			return r.Get("name").(string)
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"John", "Bill"}, names)
+	})
+}
+
+func TestScanAll(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('Bill', 50);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		rows, _ := conn.Query(ctx, table.SelectQueryOrderBy("id"))
+		records, err := pgxrecord.ScanAll(rows, table.RowToRecord)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "John", records[0].Get("name"))
+
+		rows, _ = conn.Query(ctx, `select name from t order by id`)
+		names, err := pgxrecord.ScanAll(rows, pgx.RowTo[string])
+		require.NoError(t, err)
+		require.Equal(t, []string{"John", "Bill"}, names)
+	})
+}
+
+func TestScanAllError(t *testing.T) {
+	t.Parallel()
+
+	rowsErr := errors.New("rows failed")
+	_, err := pgxrecord.ScanAll(&errRows{err: rowsErr}, pgx.RowTo[string])
+	require.ErrorIs(t, err, rowsErr)
+}
+
+func TestRecordSetAndGet(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+
+		record.Set("name", "John")
+		name := record.Get("name")
+		require.Equal(t, "John", name)
+	})
+}
+
+func TestRecordGetAndSetByIndex(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	idx, ok := table.ColumnIndex("name")
+	require.True(t, ok)
+	require.Equal(t, 1, idx)
+
+	idx, ok = table.ColumnIndex("missing")
+	require.False(t, ok)
+	require.Equal(t, 0, idx)
+
+	record := table.NewRecord()
+	nameIdx, _ := table.ColumnIndex("name")
+	record.SetByIndex(nameIdx, "John")
+	require.Equal(t, "John", record.GetByIndex(nameIdx))
+	require.Equal(t, "John", record.Get("name"))
+	require.Equal(t, []string{"name"}, record.AssignedAttributeNames())
+
+	require.Panics(t, func() { record.GetByIndex(2) })
+}
+
+func TestRecordSetNil(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	record.Set("age", int32(42))
+	record.SetNil("age")
+	require.Nil(t, record.Get("age"))
+	require.Equal(t, []string{"age"}, record.AssignedAttributeNames())
+
+	require.PanicsWithValue(t, `pgxrecord.Record ("t"): SetNil: attribute "name" does not allow null values`, func() { record.SetNil("name") })
+	require.Panics(t, func() { record.SetNil("missing") })
+}
+
+func TestRecordSetIfEmpty(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	record.SetIfEmpty("name", "John")
+	require.Equal(t, "John", record.Get("name"))
+
+	record.SetIfEmpty("name", "Jane")
+	require.Equal(t, "John", record.Get("name"))
+
+	record.Set("age", pgtype.Int4{})
+	record.SetIfEmpty("age", int32(30))
+	require.Equal(t, int32(30), record.Get("age"))
+
+	record.Set("name", "")
+	record.SetIfEmpty("name", "Jane")
+	require.Equal(t, "Jane", record.Get("name"))
+}
+
+func TestGetTyped(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	record.Set("name", "John")
+
+	name, ok := pgxrecord.GetTyped[string](record, "name")
+	require.True(t, ok)
+	require.Equal(t, "John", name)
+
+	n, ok := pgxrecord.GetTyped[int32](record, "name")
+	require.False(t, ok)
+	require.Zero(t, n)
+
+	_, ok = pgxrecord.GetTyped[int32](record, "id")
+	require.False(t, ok)
+}
+
+func TestRecordSaveInsert(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+)`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		record := table.NewRecord()
+		err = record.SetAttributesStrict(map[string]any{"name": "John", "age": 42})
+		require.NoError(t, err)
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+	})
+}
+
+func TestRecordSaveNoColumns(t *testing.T) {
+	t.Parallel()
+
 	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
 		_, err := conn.Exec(ctx, `create temporary table t (
 	id int primary key generated by default as identity,
@@ -42,22 +1678,22 @@ func TestTableLoadAllColumns(t *testing.T) {
 		err = table.LoadAllColumns(ctx, conn)
 		require.NoError(t, err)
 
-		require.Len(t, table.Columns, 3)
-		expectedColumns := []pgxrecord.Column{
-			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
-			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
-			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
-		}
-		for i := range expectedColumns {
-			assert.Equalf(t, expectedColumns[i].Name, table.Columns[i].Name, "Column %d name", i+1)
-			assert.Equalf(t, expectedColumns[i].OID, table.Columns[i].OID, "Column %d OID", i+1)
-			assert.Equalf(t, expectedColumns[i].NotNull, table.Columns[i].NotNull, "Column %d not null", i+1)
-			assert.Equalf(t, expectedColumns[i].PrimaryKey, table.Columns[i].PrimaryKey, "Column %d primary key", i+1)
-		}
+		record := table.NewRecord()
+		err = record.Save(ctx, conn)
+		require.ErrorIs(t, err, pgxrecord.ErrNoColumns)
+
+		record.Set("name", "John")
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		// Update with no assigned attributes is a no-op.
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": nil}, record.Attributes())
 	})
 }
 
-func TestTableSelectQuery(t *testing.T) {
+func TestRecordSaveUpdate(t *testing.T) {
 	t.Parallel()
 
 	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
@@ -68,23 +1704,52 @@ func TestTableSelectQuery(t *testing.T) {
 )`)
 		require.NoError(t, err)
 
+		var id int32
+		err = conn.QueryRow(ctx, `insert into t (name, age) values ('John', 42) returning id`).Scan(&id)
+		require.NoError(t, err)
+
 		table := &pgxrecord.Table{
 			Name: pgx.Identifier{"t"},
 		}
 		err = table.LoadAllColumns(ctx, conn)
 		require.NoError(t, err)
 
-		require.Equal(t, `select "t"."id", "t"."name", "t"."age" from "t"`, table.SelectQuery())
+		record, err := table.FindByPK(ctx, conn, id)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+
+		record.Set("name", "Bill")
+		err = record.Save(ctx, conn)
+		require.NoError(t, err)
+
+		record, err = table.FindByPK(ctx, conn, id)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "Bill", "age": int32(42)}, record.Attributes())
 	})
 }
 
-func TestTableNewRecord(t *testing.T) {
+func TestNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("wrapped: %w", &pgxrecord.NotFoundError{Table: `"t"`, PK: []any{1}})
+	require.ErrorIs(t, err, pgxrecord.ErrNotFound)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	require.True(t, pgxrecord.IsNotFound(err))
+
+	require.False(t, errors.Is(pgx.ErrNoRows, pgxrecord.ErrNotFound))
+	require.False(t, pgxrecord.IsNotFound(pgx.ErrNoRows))
+
+	require.EqualError(t, &pgxrecord.NotFoundError{Table: `"t"`, PK: []any{1}}, `"t" with primary key [1] not found`)
+	require.EqualError(t, &pgxrecord.NotFoundError{Table: `"t"`, Columns: []string{"name"}, Values: []any{"John"}}, `"t" with [name] = [John] not found`)
+}
+
+func TestRecordSaveConstraintError(t *testing.T) {
 	t.Parallel()
 
 	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
 		_, err := conn.Exec(ctx, `create temporary table t (
 	id int primary key generated by default as identity,
-	name text not null,
+	name text not null constraint t_name_unique unique,
 	age int
 )`)
 		require.NoError(t, err)
@@ -96,42 +1761,50 @@ func TestTableNewRecord(t *testing.T) {
 		require.NoError(t, err)
 
 		record := table.NewRecord()
-		require.Equal(t, map[string]any{"id": nil, "name": nil, "age": nil}, record.Attributes())
-
-		err = record.SetAttributesStrict(map[string]any{"name": "John", "age": 42})
+		record.Set("name", "John")
+		err = record.Save(ctx, conn)
 		require.NoError(t, err)
-		require.Equal(t, map[string]any{"id": nil, "name": "John", "age": 42}, record.Attributes())
+
+		record = table.NewRecord()
+		record.Set("name", "John")
+		err = record.Save(ctx, conn)
+		require.Error(t, err)
+
+		ce, ok := pgxrecord.IsConstraintError(err)
+		require.True(t, ok)
+		require.Equal(t, "t_name_unique", ce.Constraint)
+		require.Equal(t, "23505", ce.Err.Code)
+
+		_, ok = pgxrecord.IsConstraintError(fmt.Errorf("other error"))
+		require.False(t, ok)
 	})
 }
 
-func TestTableFindByPK(t *testing.T) {
+func TestRecordSaveDefaultSaveTimeout(t *testing.T) {
 	t.Parallel()
 
 	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
 		_, err := conn.Exec(ctx, `create temporary table t (
 	id int primary key generated by default as identity,
-	name text not null,
-	age int
+	name text not null default pg_sleep(1)::text
 )`)
 		require.NoError(t, err)
 
-		var id int32
-		err = conn.QueryRow(ctx, `insert into t (name, age) values ('John', 42) returning id`).Scan(&id)
-		require.NoError(t, err)
-
 		table := &pgxrecord.Table{
-			Name: pgx.Identifier{"t"},
+			Name:               pgx.Identifier{"t"},
+			DefaultSaveTimeout: 50 * time.Millisecond,
 		}
 		err = table.LoadAllColumns(ctx, conn)
 		require.NoError(t, err)
 
-		record, err := table.FindByPK(ctx, conn, id)
-		require.NoError(t, err)
-		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+		record := table.NewRecord()
+		record.Set("id", 1)
+		err = record.Save(ctx, conn)
+		require.True(t, pgconn.Timeout(err))
 	})
 }
 
-func TestRecordSetAndGet(t *testing.T) {
+func TestTableInsertReturning(t *testing.T) {
 	t.Parallel()
 
 	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
@@ -139,7 +1812,8 @@ func TestRecordSetAndGet(t *testing.T) {
 	id int primary key generated by default as identity,
 	name text not null,
 	age int
-)`)
+);
+insert into t (name, age) values ('John', 42), ('Jane', 40);`)
 		require.NoError(t, err)
 
 		table := &pgxrecord.Table{
@@ -149,20 +1823,74 @@ func TestRecordSetAndGet(t *testing.T) {
 		require.NoError(t, err)
 
 		record := table.NewRecord()
+		record.Set("name", "Bill")
 
-		record.Set("name", "John")
-		name := record.Get("name")
-		require.Equal(t, "John", name)
+		var total int64
+		var upperName string
+		returned, err := table.InsertReturning(ctx, conn, record, []string{"(select count(*) from t)", "upper(name)"}, &total, &upperName)
+		require.NoError(t, err)
+		require.Same(t, record, returned)
+		require.Equal(t, map[string]any{"id": int32(3), "name": "Bill", "age": nil}, record.Attributes())
+		require.EqualValues(t, 2, total)
+		require.Equal(t, "BILL", upperName)
+
+		_, err = table.InsertReturning(ctx, conn, record, nil)
+		require.ErrorContains(t, err, "not new")
+
+		record = table.NewRecord()
+		record.Set("name", "Mary")
+		_, err = table.InsertReturning(ctx, conn, record, []string{"1"})
+		require.ErrorContains(t, err, "1 extra columns but 0 extra targets")
+
+		var extra any
+		_, err = table.InsertReturning(ctx, conn, record, []string{""}, &extra)
+		require.ErrorContains(t, err, "extra column 0 is empty")
 	})
 }
 
-func TestRecordSaveInsert(t *testing.T) {
+func TestTableValidateRecord(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+		Normalize: func(ctx context.Context, db pgxrecord.DB, table *pgxrecord.Table, record *pgxrecord.Record) error {
+			if name, ok := record.Get("name").(string); ok {
+				record.Set("name", strings.TrimSpace(name))
+			}
+			return nil
+		},
+		Validate: func(ctx context.Context, db pgxrecord.DB, table *pgxrecord.Table, record *pgxrecord.Record) error {
+			return record.ValidatePresence("name")
+		},
+	}
+
+	record := table.NewRecord()
+	record.Set("name", "  ")
+	err := table.ValidateRecord(context.Background(), nil, record)
+	var ve *pgxrecord.ValidationErrors
+	require.ErrorAs(t, err, &ve)
+	require.True(t, record.HasErrors())
+	require.Equal(t, "", record.Get("name"))
+
+	record.Set("name", " John ")
+	err = table.ValidateRecord(context.Background(), nil, record)
+	require.NoError(t, err)
+	require.False(t, record.HasErrors())
+	require.Equal(t, "John", record.Get("name"))
+	require.Equal(t, []string{"name"}, record.AssignedAttributeNames())
+}
+
+func TestTableInsertDefaultValues(t *testing.T) {
 	t.Parallel()
 
 	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
 		_, err := conn.Exec(ctx, `create temporary table t (
 	id int primary key generated by default as identity,
-	name text not null,
+	name text not null default 'unnamed',
 	age int
 )`)
 		require.NoError(t, err)
@@ -173,29 +1901,135 @@ func TestRecordSaveInsert(t *testing.T) {
 		err = table.LoadAllColumns(ctx, conn)
 		require.NoError(t, err)
 
-		record := table.NewRecord()
-		err = record.SetAttributesStrict(map[string]any{"name": "John", "age": 42})
+		record, err := table.InsertDefaultValues(ctx, conn)
 		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "name": "unnamed", "age": nil}, record.Attributes())
+
+		record.Set("age", int32(42))
 		err = record.Save(ctx, conn)
 		require.NoError(t, err)
 
-		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+		record, err = table.FindByPK(ctx, conn, int32(1))
+		require.NoError(t, err)
+		require.Equal(t, int32(42), record.Get("age"))
 	})
 }
 
-func TestRecordSaveUpdate(t *testing.T) {
+func TestTableInsertAll(t *testing.T) {
 	t.Parallel()
 
 	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
 		_, err := conn.Exec(ctx, `create temporary table t (
 	id int primary key generated by default as identity,
-	name text not null,
+	name text not null unique,
 	age int
 )`)
 		require.NoError(t, err)
 
-		var id int32
-		err = conn.QueryRow(ctx, `insert into t (name, age) values ('John', 42) returning id`).Scan(&id)
+		validateCallCount := 0
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+			Validate: func(ctx context.Context, db pgxrecord.DB, table *pgxrecord.Table, record *pgxrecord.Record) error {
+				validateCallCount++
+				if record.Get("name") == "" {
+					ve := &pgxrecord.ValidationErrors{}
+					ve.Add("name", fmt.Errorf("cannot be blank"))
+					return ve
+				}
+				return nil
+			},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		records := make([]*pgxrecord.Record, 4)
+		for i, name := range []string{"John", "", "John", "Bill"} {
+			records[i] = table.NewRecord()
+			records[i].Set("name", name)
+		}
+
+		err = pgxrecord.WithTransaction(ctx, conn, func(tx pgx.Tx) error {
+			inserted, errs := table.InsertAll(ctx, tx, records)
+			require.Equal(t, 4, validateCallCount)
+			require.Len(t, errs, 4)
+			require.NoError(t, errs[0])
+			var ve *pgxrecord.ValidationErrors
+			require.ErrorAs(t, errs[1], &ve)
+			_, ok := pgxrecord.IsConstraintError(errs[2])
+			require.True(t, ok)
+			require.NoError(t, errs[3])
+
+			require.Equal(t, []*pgxrecord.Record{records[0], records[3]}, inserted)
+			require.NotNil(t, records[0].Get("id"))
+			require.NotNil(t, records[3].Get("id"))
+			return nil
+		})
+		require.NoError(t, err)
+
+		var count int
+		err = conn.QueryRow(ctx, `select count(*) from t`).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+	})
+}
+
+func TestTableInsertUpdateDeleteSQL(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	_, _, err := table.InsertSQL(record)
+	require.ErrorIs(t, err, pgxrecord.ErrNoColumns)
+
+	record.Set("id", int32(1))
+	record.Set("name", "John")
+
+	sql, args, err := table.InsertSQL(record)
+	require.NoError(t, err)
+	require.Equal(t, `insert into "t" ("id", "name") values ($1, $2) returning "id", "name", "age"`, sql)
+	require.Equal(t, []any{int32(1), "John"}, args)
+
+	sql, args, err = table.UpdateSQL(record)
+	require.NoError(t, err)
+	require.Equal(t, `update "t" set "id" = $2, "name" = $3 where "id" = $1 returning "id", "name", "age"`, sql)
+	require.Equal(t, []any{int32(1), int32(1), "John"}, args)
+
+	sql, args, err = table.DeleteSQL(record)
+	require.NoError(t, err)
+	require.Equal(t, `delete from "t" where "id" = $1`, sql)
+	require.Equal(t, []any{int32(1)}, args)
+
+	noPKTable := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+	noPKRecord := noPKTable.NewRecord()
+	noPKRecord.Set("name", "John")
+	_, _, err = noPKTable.UpdateSQL(noPKRecord)
+	require.ErrorContains(t, err, "no primary key")
+	_, _, err = noPKTable.DeleteSQL(noPKRecord)
+	require.ErrorContains(t, err, "no primary key")
+}
+
+func TestTableUpsertOnConstraint(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	email text not null constraint t_email_unique unique,
+	name text not null
+)`)
 		require.NoError(t, err)
 
 		table := &pgxrecord.Table{
@@ -204,17 +2038,20 @@ func TestRecordSaveUpdate(t *testing.T) {
 		err = table.LoadAllColumns(ctx, conn)
 		require.NoError(t, err)
 
-		record, err := table.FindByPK(ctx, conn, id)
+		record := table.NewRecord()
+		record.SetAttributes(map[string]any{"email": "john@example.com", "name": "John"})
+		err = table.UpsertOnConstraint(ctx, conn, record, "t_email_unique")
 		require.NoError(t, err)
-		require.Equal(t, map[string]any{"id": int32(1), "name": "John", "age": int32(42)}, record.Attributes())
+		require.Equal(t, map[string]any{"id": int32(1), "email": "john@example.com", "name": "John"}, record.Attributes())
 
-		record.Set("name", "Bill")
-		err = record.Save(ctx, conn)
+		record = table.NewRecord()
+		record.SetAttributes(map[string]any{"email": "john@example.com", "name": "Johnny"})
+		err = table.UpsertOnConstraint(ctx, conn, record, "t_email_unique")
 		require.NoError(t, err)
+		require.Equal(t, map[string]any{"id": int32(1), "email": "john@example.com", "name": "Johnny"}, record.Attributes())
 
-		record, err = table.FindByPK(ctx, conn, id)
-		require.NoError(t, err)
-		require.Equal(t, map[string]any{"id": int32(1), "name": "Bill", "age": int32(42)}, record.Attributes())
+		err = table.UpsertOnConstraint(ctx, conn, table.NewRecord(), "")
+		require.Error(t, err)
 	})
 }
 
@@ -303,6 +2140,7 @@ func TestRecordSaveValidate(t *testing.T) {
 		require.Error(t, err)
 		require.EqualValues(t, 1, validateCallCount)
 		require.EqualValues(t, 2, record.Errors().Len())
+		require.True(t, record.HasErrors())
 		nameErrors := record.Errors().On("name")
 		require.Len(t, nameErrors, 1)
 		require.Equal(t, "name: cannot be blank", nameErrors[0].Error())
@@ -318,6 +2156,7 @@ func TestRecordSaveValidate(t *testing.T) {
 		require.NoError(t, err)
 		require.EqualValues(t, 2, validateCallCount)
 		require.Nil(t, record.Errors())
+		require.False(t, record.HasErrors())
 
 		// Update calls Validate
 		err = record.SetAttributesStrict(map[string]any{"name": nil})
@@ -329,6 +2168,241 @@ func TestRecordSaveValidate(t *testing.T) {
 	})
 }
 
+func TestRecordSaveValidateConcurrentRecords(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+		Validate: func(ctx context.Context, db pgxrecord.DB, table *pgxrecord.Table, record *pgxrecord.Record) error {
+			ve := &pgxrecord.ValidationErrors{}
+			ve.Add("name", fmt.Errorf("%v is invalid", record.Get("name")))
+			return ve
+		},
+	}
+
+	records := make([]*pgxrecord.Record, 10)
+	for i := range records {
+		records[i] = table.NewRecord()
+		records[i].Set("name", fmt.Sprintf("name%d", i))
+	}
+
+	var wg sync.WaitGroup
+	for _, record := range records {
+		wg.Add(1)
+		go func(record *pgxrecord.Record) {
+			defer wg.Done()
+			record.Save(context.Background(), nil)
+		}(record)
+	}
+	wg.Wait()
+
+	for i, record := range records {
+		require.Equal(t, fmt.Sprintf("name: name%d is invalid", i), record.Errors().Error())
+	}
+}
+
+func TestRecordAttributeNames(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	require.Equal(t, []string{"id", "name", "age"}, record.AttributeNames())
+	require.Nil(t, record.AssignedAttributeNames())
+
+	record.Set("age", 42)
+	record.Set("id", 1)
+	require.Equal(t, []string{"id", "age"}, record.AssignedAttributeNames())
+}
+
+func TestRecordsToMaps(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	r1 := table.NewRecord()
+	r1.SetAttributes(map[string]any{"id": int32(1), "name": "John"})
+	r2 := table.NewRecord()
+	r2.SetAttributes(map[string]any{"id": int32(2), "name": "Bill"})
+
+	require.Equal(t, map[string]any{"id": int32(1), "name": "John"}, pgxrecord.RecordToMap(r1))
+	require.Equal(t,
+		[]map[string]any{{"id": int32(1), "name": "John"}, {"id": int32(2), "name": "Bill"}},
+		pgxrecord.RecordsToMaps([]*pgxrecord.Record{r1, r2}),
+	)
+}
+
+func TestEachRecord(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+		},
+	}
+
+	records := make([]*pgxrecord.Record, 10)
+	for i := range records {
+		records[i] = table.NewRecord()
+		records[i].Set("id", int32(i))
+	}
+
+	var ids []int32
+	err := pgxrecord.EachRecord(records, func(r *pgxrecord.Record) error {
+		ids = append(ids, r.Get("id").(int32))
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, ids)
+
+	stopErr := errors.New("stop")
+	ids = nil
+	err = pgxrecord.EachRecord(records, func(r *pgxrecord.Record) error {
+		ids = append(ids, r.Get("id").(int32))
+		if r.Get("id") == int32(3) {
+			return stopErr
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stopErr)
+	require.Equal(t, []int32{0, 1, 2, 3}, ids)
+}
+
+func TestCopyRecord(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	dst := table.NewRecord()
+	dst.Set("name", "John")
+
+	src := table.NewRecord()
+	src.Set("age", int32(42))
+
+	err := pgxrecord.CopyRecord(dst, src)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"id": nil, "name": nil, "age": int32(42)}, dst.Attributes())
+	require.Equal(t, []string{"name", "age"}, dst.AssignedAttributeNames())
+
+	otherTable := &pgxrecord.Table{
+		Name: pgx.Identifier{"other"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+		},
+	}
+	err = pgxrecord.CopyRecord(dst, otherTable.NewRecord())
+	require.ErrorContains(t, err, `"other"`)
+}
+
+func TestRecordScan(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	record.SetAttributes(map[string]any{"id": int32(1), "name": "John", "age": nil})
+
+	var id int64
+	var name string
+	var age pgtype.Int4
+	err := record.Scan(&id, &name, &age)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, id)
+	require.Equal(t, "John", name)
+	require.False(t, age.Valid)
+
+	err = record.Scan(&id, &name)
+	require.Error(t, err)
+
+	var notNullAge int32
+	err = record.Scan(nil, nil, &notNullAge)
+	require.Error(t, err)
+}
+
+func TestRecordScanColumnsWithoutOID(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", NotNull: true, PrimaryKey: true},
+			{Name: "name", NotNull: true, PrimaryKey: false},
+			{Name: "age", NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	record.SetAttributes(map[string]any{"id": int32(1), "name": "John", "age": nil})
+
+	var id int64
+	var name string
+	var age pgtype.Int4
+	err := record.Scan(&id, &name, &age)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, id)
+	require.Equal(t, "John", name)
+	require.False(t, age.Valid)
+}
+
+func TestRecordJSON(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "age", OID: pgtype.Int4OID, NotNull: false, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	record.SetAttributes(map[string]any{"id": int32(1), "name": "John", "age": nil})
+	buf, err := json.Marshal(record)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id": 1, "name": "John", "age": null}`, string(buf))
+
+	record = table.NewRecord()
+	err = json.Unmarshal([]byte(`{"name": "Bill", "age": 42}`), record)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"id": nil, "name": "Bill", "age": int32(42)}, record.Attributes())
+
+	err = json.Unmarshal([]byte(`{"missing": 1}`), table.NewRecord())
+	require.Error(t, err)
+}
+
 func TestRecordUpdateAttributes(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,63 @@
+package pgxrecord
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RecordPool is a pool of records of a single table. It can reduce allocations when many short-lived records are
+// read. It is safe for concurrent use.
+type RecordPool struct {
+	table *Table
+	pool  sync.Pool
+}
+
+// NewRecordPool creates a RecordPool for table.
+func NewRecordPool(table *Table) *RecordPool {
+	p := &RecordPool{table: table}
+	p.pool.New = func() any {
+		return table.NewRecord()
+	}
+
+	return p
+}
+
+// Get returns an empty Record from the pool.
+func (p *RecordPool) Get() *Record {
+	record := p.pool.Get().(*Record)
+	for i := range record.attributes {
+		record.attributes[i] = nil
+		record.assigned[i] = false
+	}
+	for i := range record.computedAttributes {
+		record.computedAttributes[i] = nil
+	}
+	record.originalAttributes = nil
+	record.validationErrors = nil
+
+	return record
+}
+
+// Put returns record to the pool. record must not be used after it is returned to the pool. It panics if record does
+// not belong to the pool's table.
+func (p *RecordPool) Put(record *Record) {
+	if record.table != p.table {
+		panic(fmt.Sprintf("pgxrecord.RecordPool (%s): Put: record belongs to table %s", p.table.quotedQualifiedName, record.table.quotedQualifiedName))
+	}
+
+	p.pool.Put(record)
+}
+
+// RowToRecord is a pgx.RowToFunc that returns a *Record from the pool.
+func (p *RecordPool) RowToRecord(row pgx.CollectableRow) (*Record, error) {
+	record := p.Get()
+	err := scanRecord(row, record)
+	if err != nil {
+		p.pool.Put(record)
+		return nil, fmt.Errorf("pgxrecord.RecordPool (%s): RowToRecord: %w", p.table.quotedQualifiedName, err)
+	}
+
+	return record, nil
+}
@@ -0,0 +1,126 @@
+package pgxrecord
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// prepareSeq makes prepared statement names unique so multiple tables, including tables over the same relation, can
+// prepare statements on the same connection.
+var prepareSeq uint64
+
+// PrepareStatements prepares the select by primary key, insert, and update statements on conn. The insert statement is
+// prepared for the case where exactly the columns without a default are assigned. The update statement is prepared for
+// the case where exactly the non-primary key columns without a default are assigned. Columns with a default, including
+// identity and generated columns, are excluded as they may not be writable. FindByPK and Save use the prepared
+// statements when called with conn or a pgx.Tx on conn. Statements can only be prepared on one connection at a time.
+// PrepareStatements must not be called concurrently with any other method.
+func (t *Table) PrepareStatements(ctx context.Context, conn *pgx.Conn) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.preparedConn != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): PrepareStatements: statements already prepared", t.quotedQualifiedName)
+	}
+
+	insertAssigned := make([]bool, len(t.Columns))
+	updateAssigned := make([]bool, len(t.Columns))
+	var insertCount, updateCount int
+	for i, c := range t.Columns {
+		if c.HasDefault {
+			continue
+		}
+		insertAssigned[i] = true
+		insertCount++
+		if !c.PrimaryKey {
+			updateAssigned[i] = true
+			updateCount++
+		}
+	}
+
+	seq := atomic.AddUint64(&prepareSeq, 1)
+	statements := map[string]string{}
+	if insertCount > 0 {
+		statements[t.buildInsertSQL(insertAssigned)] = fmt.Sprintf("pgxrecord_%d_insert", seq)
+	}
+	if !t.noPrimaryKey {
+		statements[t.selectByPKQuery] = fmt.Sprintf("pgxrecord_%d_select_by_pk", seq)
+		if updateCount > 0 {
+			statements[t.buildUpdateSQL(updateAssigned)] = fmt.Sprintf("pgxrecord_%d_update", seq)
+		}
+	}
+
+	prepared := make([]string, 0, len(statements))
+	for sql, name := range statements {
+		_, err := conn.Prepare(ctx, name, sql)
+		if err != nil {
+			for _, name := range prepared {
+				deallocateErr := conn.Deallocate(ctx, name)
+				if deallocateErr != nil {
+					return fmt.Errorf("pgxrecord.Table (%s): PrepareStatements: %w (deallocate %s failed: %v)", t.quotedQualifiedName, err, name, deallocateErr)
+				}
+			}
+			return fmt.Errorf("pgxrecord.Table (%s): PrepareStatements: %w", t.quotedQualifiedName, err)
+		}
+		prepared = append(prepared, name)
+	}
+
+	t.preparedConn = conn
+	t.preparedStatements = statements
+
+	return nil
+}
+
+// UnprepareStatements deallocates the statements prepared by PrepareStatements. It must not be called concurrently
+// with any other method.
+func (t *Table) UnprepareStatements(ctx context.Context, conn *pgx.Conn) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.preparedConn != conn {
+		return fmt.Errorf("pgxrecord.Table (%s): UnprepareStatements: statements not prepared on conn", t.quotedQualifiedName)
+	}
+
+	for _, name := range t.preparedStatements {
+		err := conn.Deallocate(ctx, name)
+		if err != nil {
+			return fmt.Errorf("pgxrecord.Table (%s): UnprepareStatements: %w", t.quotedQualifiedName, err)
+		}
+	}
+
+	t.preparedConn = nil
+	t.preparedStatements = nil
+
+	return nil
+}
+
+// statementSQL returns the name of the prepared statement for sql if it has been prepared on the connection used by
+// db. Otherwise it returns sql.
+func (t *Table) statementSQL(db DB, sql string) string {
+	if t.preparedConn == nil {
+		return sql
+	}
+
+	var conn *pgx.Conn
+	switch db := db.(type) {
+	case *pgx.Conn:
+		conn = db
+	case pgx.Tx:
+		conn = db.Conn()
+	}
+
+	if conn != t.preparedConn {
+		return sql
+	}
+
+	if name, ok := t.preparedStatements[sql]; ok {
+		return name
+	}
+
+	return sql
+}
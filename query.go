@@ -0,0 +1,181 @@
+package pgxrecord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TableQuery builds a select query for a table. It is created with Table.Query. All methods other than SQL and
+// Execute modify the query and return it to allow chaining.
+//
+// Placeholders are numbered in the order arguments are added to the query regardless of where the SQL fragment
+// appears in the final query. For example, q.WithCTE("a", "select $1", 1).Where("id = $2", 2).
+type TableQuery struct {
+	table     *Table
+	recursive bool
+	ctes      []string
+	where     []string
+	orderBy   []string
+	args      []any
+	limit     *int64
+	offset    *int64
+	forUpdate bool
+}
+
+// Query returns a new TableQuery that selects all columns of t.
+func (t *Table) Query() *TableQuery {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	return &TableQuery{table: t}
+}
+
+// NamedScope registers fn as a reusable query composition named name. It is applied with Table.ApplyScope or
+// TableQuery.ApplyScope. It panics if called after the table is finalized or if name is already registered.
+func (t *Table) NamedScope(name string, fn func(*TableQuery) *TableQuery) {
+	if t.finalized {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): NamedScope: cannot call after table finalized", t.quotedQualifiedName))
+	}
+
+	if _, ok := t.namedScopes[name]; ok {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): NamedScope: scope %q already exists", t.Name.Sanitize(), name))
+	}
+
+	if t.namedScopes == nil {
+		t.namedScopes = make(map[string]func(*TableQuery) *TableQuery)
+	}
+	t.namedScopes[name] = fn
+}
+
+// ApplyScope returns a new TableQuery with the scope named name applied. It returns an error if the scope is not
+// registered.
+func (t *Table) ApplyScope(name string) (*TableQuery, error) {
+	return t.Query().ApplyScope(name)
+}
+
+// ApplyScope applies the scope named name to q. It returns an error if the scope is not registered.
+func (q *TableQuery) ApplyScope(name string) (*TableQuery, error) {
+	fn, ok := q.table.namedScopes[name]
+	if !ok {
+		return nil, fmt.Errorf("pgxrecord.TableQuery (%s): ApplyScope: scope %q is not found", q.table.quotedQualifiedName, name)
+	}
+
+	return fn(q), nil
+}
+
+// WithCTE returns a new TableQuery with a common table expression.
+func (t *Table) WithCTE(name, cteSQL string, args ...any) *TableQuery {
+	return t.Query().WithCTE(name, cteSQL, args...)
+}
+
+// WithCTE adds a common table expression named name.
+func (q *TableQuery) WithCTE(name, cteSQL string, args ...any) *TableQuery {
+	q.ctes = append(q.ctes, pgx.Identifier{name}.Sanitize()+" as ("+cteSQL+")")
+	q.args = append(q.args, args...)
+	return q
+}
+
+// WithRecursiveCTE adds a recursive common table expression named name.
+func (q *TableQuery) WithRecursiveCTE(name, cteSQL string, args ...any) *TableQuery {
+	q.recursive = true
+	return q.WithCTE(name, cteSQL, args...)
+}
+
+// Where adds a condition to the where clause. Multiple conditions are combined with and.
+func (q *TableQuery) Where(whereSQL string, args ...any) *TableQuery {
+	q.where = append(q.where, whereSQL)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// OrderBy adds an expression to the order by clause.
+func (q *TableQuery) OrderBy(orderSQL string) *TableQuery {
+	q.orderBy = append(q.orderBy, orderSQL)
+	return q
+}
+
+// Limit sets the maximum number of rows returned.
+func (q *TableQuery) Limit(n int64) *TableQuery {
+	q.limit = &n
+	return q
+}
+
+// Offset sets the number of rows to skip.
+func (q *TableQuery) Offset(n int64) *TableQuery {
+	q.offset = &n
+	return q
+}
+
+// ForUpdate locks the selected rows with for update.
+func (q *TableQuery) ForUpdate() *TableQuery {
+	q.forUpdate = true
+	return q
+}
+
+// SQL returns the SQL and arguments for the query.
+func (q *TableQuery) SQL() (string, []any) {
+	args := make([]any, len(q.args), len(q.args)+2)
+	copy(args, q.args)
+
+	b := &strings.Builder{}
+	if len(q.ctes) > 0 {
+		b.WriteString("with ")
+		if q.recursive {
+			b.WriteString("recursive ")
+		}
+		b.WriteString(strings.Join(q.ctes, ", "))
+		b.WriteByte(' ')
+	}
+
+	b.WriteString(q.table.selectQuery)
+
+	for i, w := range q.where {
+		if i == 0 {
+			b.WriteString(" where (")
+		} else {
+			b.WriteString(" and (")
+		}
+		b.WriteString(w)
+		b.WriteByte(')')
+	}
+
+	if len(q.orderBy) > 0 {
+		b.WriteString(" order by ")
+		b.WriteString(strings.Join(q.orderBy, ", "))
+	}
+
+	if q.limit != nil {
+		args = append(args, *q.limit)
+		b.WriteString(" limit $")
+		b.WriteString(strconv.FormatInt(int64(len(args)), 10))
+	}
+
+	if q.offset != nil {
+		args = append(args, *q.offset)
+		b.WriteString(" offset $")
+		b.WriteString(strconv.FormatInt(int64(len(args)), 10))
+	}
+
+	if q.forUpdate {
+		b.WriteString(" for update")
+	}
+
+	return b.String(), args
+}
+
+// Execute runs the query and returns the records.
+func (q *TableQuery) Execute(ctx context.Context, db DB) ([]*Record, error) {
+	sql, args := q.SQL()
+	rows, _ := db.Query(ctx, sql, args...)
+	records, err := pgx.CollectRows(rows, q.table.RowToRecord)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.TableQuery (%s): Execute: %w", q.table.quotedQualifiedName, err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,110 @@
+package pgxrecord_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableQuerySQL(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	sql, args := table.Query().SQL()
+	require.Equal(t, `select "t"."id", "t"."name" from "t"`, sql)
+	require.Empty(t, args)
+
+	sql, args = table.WithCTE("ids", "select $1::int as id", 1).
+		Where("id in (select id from ids)").
+		Where("name <> $2", "John").
+		OrderBy("name").
+		OrderBy("id desc").
+		Limit(10).
+		Offset(20).
+		ForUpdate().
+		SQL()
+	require.Equal(t, `with "ids" as (select $1::int as id) select "t"."id", "t"."name" from "t" where (id in (select id from ids)) and (name <> $2) order by name, id desc limit $3 offset $4 for update`, sql)
+	require.Equal(t, []any{1, "John", int64(10), int64(20)}, args)
+
+	sql, _ = table.Query().WithRecursiveCTE("r", "select 1 union all select 1 from r").SQL()
+	require.Equal(t, `with recursive "r" as (select 1 union all select 1 from r) select "t"."id", "t"."name" from "t"`, sql)
+}
+
+func TestTableQueryExecute(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('Bill', 50), ('George', 30);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		records, err := table.Query().Where("age > $1", 35).OrderBy("age desc").Execute(ctx, conn)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "Bill", records[0].Get("name"))
+		require.Equal(t, "John", records[1].Get("name"))
+
+		records, err = table.Query().OrderBy("id").Limit(1).Offset(1).Execute(ctx, conn)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.Equal(t, "Bill", records[0].Get("name"))
+	})
+}
+
+func TestTableNamedScope(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+			{Name: "active", OID: pgtype.BoolOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+	table.NamedScope("active", func(q *pgxrecord.TableQuery) *pgxrecord.TableQuery {
+		return q.Where("active")
+	})
+	table.NamedScope("recent", func(q *pgxrecord.TableQuery) *pgxrecord.TableQuery {
+		return q.OrderBy("id desc").Limit(10)
+	})
+	require.Panics(t, func() { table.NamedScope("active", nil) })
+
+	q, err := table.ApplyScope("active")
+	require.NoError(t, err)
+	q, err = q.ApplyScope("recent")
+	require.NoError(t, err)
+
+	sql, args := q.SQL()
+	require.Equal(t, `select "t"."id", "t"."name", "t"."active" from "t" where (active) order by id desc limit $1`, sql)
+	require.Equal(t, []any{int64(10)}, args)
+
+	_, err = table.ApplyScope("missing")
+	require.ErrorContains(t, err, `scope "missing" is not found`)
+
+	_, err = table.Query().ApplyScope("missing")
+	require.Error(t, err)
+
+	require.Panics(t, func() { table.NamedScope("other", nil) })
+}
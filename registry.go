@@ -0,0 +1,54 @@
+package pgxrecord
+
+import (
+	"fmt"
+	"sync"
+)
+
+// TableRegistry is a set of tables that can be looked up by name. The zero value is ready to use. It is safe for
+// concurrent use.
+type TableRegistry struct {
+	mu     sync.RWMutex
+	tables map[string]*Table
+}
+
+// DefaultRegistry is a TableRegistry for applications that do not need more than one registry.
+var DefaultRegistry = &TableRegistry{}
+
+// Register adds t to the registry. t is finalized. Its name is the quoted, qualified table name. e.g. "public"."widgets".
+// It panics if a table with the same name is already registered.
+func (r *TableRegistry) Register(t *Table) {
+	t.MustFinalize()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tables[t.quotedQualifiedName]; ok {
+		panic(fmt.Sprintf("pgxrecord.TableRegistry: Register: table %s is already registered", t.quotedQualifiedName))
+	}
+
+	if r.tables == nil {
+		r.tables = make(map[string]*Table)
+	}
+	r.tables[t.quotedQualifiedName] = t
+}
+
+// Lookup returns the table registered as name. name is the quoted, qualified table name as returned by
+// pgx.Identifier.Sanitize.
+func (r *TableRegistry) Lookup(name string) (*Table, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tables[name]
+	return t, ok
+}
+
+// MustLookup is like Lookup but panics if the table is not registered.
+func (r *TableRegistry) MustLookup(name string) *Table {
+	t, ok := r.Lookup(name)
+	if !ok {
+		panic(fmt.Sprintf("pgxrecord.TableRegistry: MustLookup: table %s is not registered", name))
+	}
+
+	return t
+}
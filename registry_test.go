@@ -0,0 +1,35 @@
+package pgxrecord_test
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableRegistry(t *testing.T) {
+	t.Parallel()
+
+	widgets := &pgxrecord.Table{
+		Name: pgx.Identifier{"public", "widgets"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+		},
+	}
+
+	registry := &pgxrecord.TableRegistry{}
+	registry.Register(widgets)
+
+	table, ok := registry.Lookup(`"public"."widgets"`)
+	require.True(t, ok)
+	require.Same(t, widgets, table)
+	require.Same(t, widgets, registry.MustLookup(pgx.Identifier{"public", "widgets"}.Sanitize()))
+
+	_, ok = registry.Lookup(`"public"."gadgets"`)
+	require.False(t, ok)
+	require.Panics(t, func() { registry.MustLookup(`"public"."gadgets"`) })
+
+	require.Panics(t, func() { registry.Register(widgets) })
+}
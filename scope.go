@@ -0,0 +1,74 @@
+package pgxrecord
+
+import (
+	"context"
+	"fmt"
+)
+
+// ScopedTable is a Table with an application-level filter. Only records for which the filter returns true are
+// returned. It is created with Table.Scope.
+type ScopedTable struct {
+	table  *Table
+	filter func(*Record) bool
+}
+
+// Scope returns a ScopedTable that only includes records for which fn returns true.
+func (t *Table) Scope(fn func(*Record) bool) *ScopedTable {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	return &ScopedTable{table: t, filter: fn}
+}
+
+// FindAll returns all records in the table that pass the filter.
+func (st *ScopedTable) FindAll(ctx context.Context, db DB) ([]*Record, error) {
+	records, err := st.findAll(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("pgxrecord.ScopedTable (%s): FindAll: %w", st.table.quotedQualifiedName, err)
+	}
+
+	return records, nil
+}
+
+// ForEach calls fn for each record in the table that passes the filter. If fn returns an error then iteration stops
+// and the error is returned. All matching records are read before fn is called so fn may use db. e.g. to save the
+// record.
+func (st *ScopedTable) ForEach(ctx context.Context, db DB, fn func(*Record) error) error {
+	records, err := st.findAll(ctx, db)
+	if err != nil {
+		return fmt.Errorf("pgxrecord.ScopedTable (%s): ForEach: %w", st.table.quotedQualifiedName, err)
+	}
+
+	for _, record := range records {
+		err = fn(record)
+		if err != nil {
+			return fmt.Errorf("pgxrecord.ScopedTable (%s): ForEach: %w", st.table.quotedQualifiedName, err)
+		}
+	}
+
+	return nil
+}
+
+// findAll reads all records that pass the filter. The rows are closed before it returns.
+func (st *ScopedTable) findAll(ctx context.Context, db DB) ([]*Record, error) {
+	rows, err := db.Query(ctx, st.table.selectQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		record, err := st.table.RowToRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if st.filter(record) {
+			records = append(records, record)
+		}
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,65 @@
+package pgxrecord_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedTable(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (
+	id int primary key generated by default as identity,
+	name text not null,
+	age int
+);
+insert into t (name, age) values ('John', 42), ('Bill', 50), ('George', 30);`)
+		require.NoError(t, err)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"t"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		scoped := table.Scope(func(r *pgxrecord.Record) bool {
+			return r.Get("age").(int32) > 35
+		})
+
+		records, err := scoped.FindAll(ctx, conn)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+
+		var names []string
+		err = scoped.ForEach(ctx, conn, func(r *pgxrecord.Record) error {
+			names = append(names, r.Get("name").(string))
+			return nil
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"John", "Bill"}, names)
+
+		stopErr := errors.New("stop")
+		err = scoped.ForEach(ctx, conn, func(r *pgxrecord.Record) error {
+			return stopErr
+		})
+		require.ErrorIs(t, err, stopErr)
+
+		// fn can use the same connection.
+		err = scoped.ForEach(ctx, conn, func(r *pgxrecord.Record) error {
+			r.Set("age", r.Get("age").(int32)+1)
+			return r.Save(ctx, conn)
+		})
+		require.NoError(t, err)
+
+		var ageSum int64
+		err = conn.QueryRow(ctx, `select sum(age) from t`).Scan(&ageSum)
+		require.NoError(t, err)
+		require.EqualValues(t, 42+50+30+2, ageSum)
+	})
+}
@@ -0,0 +1,69 @@
+package pgxrecord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WithTransaction begins a transaction on conn and calls fn with it. If fn returns nil the transaction is committed.
+// If fn returns an error or panics the transaction is rolled back. A panic is re-raised after the rollback.
+func WithTransaction(ctx context.Context, conn *pgx.Conn, fn func(pgx.Tx) error) error {
+	return WithTransactionOptions(ctx, conn, pgx.TxOptions{}, fn)
+}
+
+// WithTransactionOptions is like WithTransaction but begins the transaction with txOptions.
+func WithTransactionOptions(ctx context.Context, conn *pgx.Conn, txOptions pgx.TxOptions, fn func(pgx.Tx) error) (err error) {
+	tx, err := conn.BeginTx(ctx, txOptions)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// WithSavepoint creates a savepoint named name and calls fn with db. If fn returns nil the savepoint is released. If
+// fn returns an error the transaction is rolled back to the savepoint, the savepoint is released, and the error is
+// returned. db must be in a transaction.
+func WithSavepoint(ctx context.Context, db DB, name string, fn func(DB) error) error {
+	quotedName := pgx.Identifier{name}.Sanitize()
+
+	err := exec(ctx, db, "savepoint "+quotedName, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(db)
+	if err != nil {
+		rollbackErr := exec(ctx, db, "rollback to savepoint "+quotedName, nil)
+		if rollbackErr == nil {
+			rollbackErr = exec(ctx, db, "release savepoint "+quotedName, nil)
+		}
+		if rollbackErr != nil {
+			return fmt.Errorf("%w (rollback to savepoint failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	return exec(ctx, db, "release savepoint "+quotedName, nil)
+}
+
+// WithDB calls fn with ctx and db and returns its result. It allows a query that returns a value to be written as an
+// inline function.
+func WithDB[T any](ctx context.Context, db DB, fn func(context.Context, DB) (T, error)) (T, error) {
+	return fn(ctx, db)
+}
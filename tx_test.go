@@ -0,0 +1,110 @@
+package pgxrecord_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTransaction(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (id int primary key)`)
+		require.NoError(t, err)
+
+		err = pgxrecord.WithTransaction(ctx, conn, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, `insert into t (id) values (1)`)
+			return err
+		})
+		require.NoError(t, err)
+
+		err = pgxrecord.WithTransaction(ctx, conn, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, `insert into t (id) values (2)`)
+			require.NoError(t, err)
+			return fmt.Errorf("rollback")
+		})
+		require.EqualError(t, err, "rollback")
+
+		require.Panics(t, func() {
+			pgxrecord.WithTransaction(ctx, conn, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, `insert into t (id) values (3)`)
+				require.NoError(t, err)
+				panic("boom")
+			})
+		})
+
+		rows, _ := conn.Query(ctx, `select id from t order by id`)
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+		require.NoError(t, err)
+		require.Equal(t, []int32{1}, ids)
+
+		err = pgxrecord.WithTransactionOptions(ctx, conn, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, `insert into t (id) values (4)`)
+			return err
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestWithSavepoint(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		_, err := conn.Exec(ctx, `create temporary table t (id int primary key)`)
+		require.NoError(t, err)
+
+		tx, err := conn.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+
+		err = pgxrecord.WithSavepoint(ctx, tx, "sp1", func(db pgxrecord.DB) error {
+			rows, err := db.Query(ctx, `insert into t (id) values (1)`)
+			if err != nil {
+				return err
+			}
+			rows.Close()
+			return rows.Err()
+		})
+		require.NoError(t, err)
+
+		err = pgxrecord.WithSavepoint(ctx, tx, "sp2", func(db pgxrecord.DB) error {
+			rows, err := db.Query(ctx, `insert into t (id) values (1)`)
+			if err != nil {
+				return err
+			}
+			rows.Close()
+			return rows.Err()
+		})
+		require.Error(t, err)
+
+		// The transaction is still usable after the failed savepoint.
+		var count int
+		err = tx.QueryRow(ctx, `select count(*) from t`).Scan(&count)
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+}
+
+func TestWithDB(t *testing.T) {
+	t.Parallel()
+
+	queryErr := fmt.Errorf("query failed")
+	db := &errDB{err: queryErr}
+
+	n, err := pgxrecord.WithDB(context.Background(), db, func(ctx context.Context, db pgxrecord.DB) (int, error) {
+		return 42, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 42, n)
+
+	_, err = pgxrecord.WithDB(context.Background(), db, func(ctx context.Context, db pgxrecord.DB) (string, error) {
+		_, err := db.Query(ctx, "select 1")
+		return "", err
+	})
+	require.ErrorIs(t, err, queryErr)
+}
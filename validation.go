@@ -1,8 +1,12 @@
 package pgxrecord
 
 import (
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type ValidationError struct {
@@ -10,6 +14,11 @@ type ValidationError struct {
 	err   error
 }
 
+// NewValidationError returns a new ValidationError for field.
+func NewValidationError(field string, err error) *ValidationError {
+	return &ValidationError{field: field, err: err}
+}
+
 func (ve *ValidationError) Field() string {
 	return ve.field
 }
@@ -65,6 +74,24 @@ func (ve *ValidationErrors) All() []*ValidationError {
 	return ve.errors
 }
 
+// First returns the first error added or nil if there are no errors.
+func (ve *ValidationErrors) First() *ValidationError {
+	if ve.Len() == 0 {
+		return nil
+	}
+
+	return ve.errors[0]
+}
+
+// Last returns the last error added or nil if there are no errors.
+func (ve *ValidationErrors) Last() *ValidationError {
+	if ve.Len() == 0 {
+		return nil
+	}
+
+	return ve.errors[len(ve.errors)-1]
+}
+
 // Unwrap unwraps all errors.
 func (ve *ValidationErrors) Unwrap() []error {
 	var errs []error
@@ -99,6 +126,52 @@ func (ve *ValidationErrors) Error() string {
 	return sb.String()
 }
 
+// ValidationErrorsFromPgError converts a constraint violation in err to validation errors. constraintMap maps
+// constraint names to the validation error to report. The values can be created with *NewValidationError(field, err).
+// Returns nil if err is not a *pgconn.PgError or the constraint is not in constraintMap.
+func ValidationErrorsFromPgError(err error, constraintMap map[string]ValidationError) *ValidationErrors {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.ConstraintName == "" {
+		return nil
+	}
+
+	e, ok := constraintMap[pgErr.ConstraintName]
+	if !ok {
+		return nil
+	}
+
+	ve := &ValidationErrors{}
+	ve.Add(e.field, e.err)
+	return ve
+}
+
+// ValidatePresence returns a *ValidationErrors with an error on field if the value of field is blank. nil, empty
+// strings, and pgtype values that are not valid are blank. It returns nil if the value is present.
+func (r *Record) ValidatePresence(field string) error {
+	if isBlank(r.Get(field)) {
+		ve := &ValidationErrors{}
+		ve.Add(field, errors.New("can't be blank"))
+		return ve
+	}
+
+	return nil
+}
+
+// isBlank returns true if value is nil, an empty string, or a driver.Valuer such as a pgtype value that returns nil.
+func isBlank(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case driver.Valuer:
+		v, err := value.Value()
+		return err == nil && v == nil
+	}
+
+	return false
+}
+
 type GetterSetter interface {
 	Get(attribute string) any
 	Set(attribute string, value any)
@@ -109,6 +182,11 @@ type RecordValidator struct {
 	errors *ValidationErrors
 }
 
+// NewRecordValidator returns a RecordValidator that validates record and adds any errors to errors.
+func NewRecordValidator(record GetterSetter, errors *ValidationErrors) *RecordValidator {
+	return &RecordValidator{record: record, errors: errors}
+}
+
 func (v *RecordValidator) Validate(field string, validators ...ValueValidator) {
 	value := v.record.Get(field)
 	for _, validator := range validators {
@@ -122,6 +200,20 @@ func (v *RecordValidator) Validate(field string, validators ...ValueValidator) {
 	v.record.Set(field, value)
 }
 
+// ValidateCrossField calls fn with the values of fields. If fn returns an error it is added as a record-level error.
+// It should be called after Validate has been called for each field so fn sees the normalized values.
+func (v *RecordValidator) ValidateCrossField(fields []string, fn func(values []any) error) {
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		values[i] = v.record.Get(field)
+	}
+
+	err := fn(values)
+	if err != nil {
+		v.errors.Add("", err)
+	}
+}
+
 type ValueValidator interface {
 	Validate(any) (any, error)
 }
@@ -0,0 +1,115 @@
+package pgxrecord_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrorsFromPgError(t *testing.T) {
+	t.Parallel()
+
+	constraintMap := map[string]pgxrecord.ValidationError{
+		"users_email_unique": *pgxrecord.NewValidationError("email", errors.New("is already taken")),
+	}
+
+	pgErr := &pgconn.PgError{Code: "23505", ConstraintName: "users_email_unique"}
+	ve := pgxrecord.ValidationErrorsFromPgError(fmt.Errorf("wrapped: %w", pgErr), constraintMap)
+	require.NotNil(t, ve)
+	require.Equal(t, 1, ve.Len())
+	require.Equal(t, "email: is already taken", ve.Error())
+
+	require.Nil(t, pgxrecord.ValidationErrorsFromPgError(&pgconn.PgError{Code: "23505", ConstraintName: "other"}, constraintMap))
+	require.Nil(t, pgxrecord.ValidationErrorsFromPgError(errors.New("not a pg error"), constraintMap))
+}
+
+func TestValidationErrorsFirstAndLast(t *testing.T) {
+	t.Parallel()
+
+	var nilErrors *pgxrecord.ValidationErrors
+	require.Nil(t, nilErrors.First())
+	require.Nil(t, nilErrors.Last())
+
+	ve := &pgxrecord.ValidationErrors{}
+	require.Nil(t, ve.First())
+	require.Nil(t, ve.Last())
+
+	ve.Add("name", errors.New("can't be blank"))
+	require.Equal(t, "name: can't be blank", ve.First().Error())
+	require.Equal(t, "name: can't be blank", ve.Last().Error())
+
+	ve.Add("age", errors.New("must be positive"))
+	require.Equal(t, "name: can't be blank", ve.First().Error())
+	require.Equal(t, "age: must be positive", ve.Last().Error())
+}
+
+type mapGetterSetter map[string]any
+
+func (m mapGetterSetter) Get(attribute string) any {
+	return m[attribute]
+}
+
+func (m mapGetterSetter) Set(attribute string, value any) {
+	m[attribute] = value
+}
+
+type trimValidator struct{}
+
+func (trimValidator) Validate(value any) (any, error) {
+	return strings.TrimSpace(value.(string)), nil
+}
+
+func TestRecordValidatorValidateCrossField(t *testing.T) {
+	t.Parallel()
+
+	record := mapGetterSetter{"start_date": " 2023-02-01 ", "end_date": "2023-01-01"}
+	ve := &pgxrecord.ValidationErrors{}
+	v := pgxrecord.NewRecordValidator(record, ve)
+
+	v.Validate("start_date", trimValidator{})
+	v.ValidateCrossField([]string{"start_date", "end_date"}, func(values []any) error {
+		require.Equal(t, []any{"2023-02-01", "2023-01-01"}, values)
+		if values[1].(string) < values[0].(string) {
+			return errors.New("end_date must be after start_date")
+		}
+		return nil
+	})
+
+	require.Equal(t, 1, ve.Len())
+	require.Len(t, ve.On(""), 1)
+	require.Equal(t, "end_date must be after start_date", ve.Error())
+}
+
+func TestRecordValidatePresence(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"t"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	record := table.NewRecord()
+	for _, value := range []any{nil, "", pgtype.Text{}} {
+		record.Set("name", value)
+		err := record.ValidatePresence("name")
+		require.EqualError(t, err, "name: can't be blank")
+		var ve *pgxrecord.ValidationErrors
+		require.ErrorAs(t, err, &ve)
+		require.Len(t, ve.On("name"), 1)
+	}
+
+	for _, value := range []any{"John", pgtype.Text{String: "John", Valid: true}} {
+		record.Set("name", value)
+		require.NoError(t, record.ValidatePresence("name"))
+	}
+}
@@ -0,0 +1,182 @@
+package pgxrecord
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// changeNotification is the payload sent by the trigger created by ChangesTriggerSQL.
+type changeNotification struct {
+	Action string   `json:"action"`
+	PK     []string `json:"pk"`
+}
+
+// maxIdentifierLength is the maximum length in bytes of a PostgreSQL identifier. Longer identifiers, including
+// channel names, are truncated or rejected by the server.
+const maxIdentifierLength = 63
+
+// shortIdentifier returns prefix + name if it fits in maxIdentifierLength. Otherwise it returns prefix + a hash of
+// name so distinct long names do not collide when truncated.
+func shortIdentifier(prefix, name string) string {
+	if len(prefix)+len(name) <= maxIdentifierLength {
+		return prefix + name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	return prefix + hex.EncodeToString(sum[:16])
+}
+
+// changesChannel returns the name of the channel that changes to the table are sent on.
+func (t *Table) changesChannel() string {
+	return shortIdentifier("pgxrecord_changes:", strings.Join(t.Name, "."))
+}
+
+// ChangesTriggerSQL returns the SQL to create the trigger and trigger function that WatchChanges depends on. The
+// trigger sends a notification with the action and primary key whenever a row is inserted, updated, or deleted. It
+// must be installed separately. e.g. in a migration. Channel and function names that would exceed the PostgreSQL
+// identifier length limit use a hash of the table name instead. It panics if the table has no primary key.
+func (t *Table) ChangesTriggerSQL() string {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.noPrimaryKey {
+		panic(fmt.Sprintf("pgxrecord.Table (%s): ChangesTriggerSQL: %v", t.quotedQualifiedName, errNoPrimaryKey))
+	}
+
+	functionName := make(pgx.Identifier, len(t.Name))
+	copy(functionName, t.Name)
+	functionName[len(functionName)-1] = shortIdentifier("pgxrecord_notify_", functionName[len(functionName)-1])
+	quotedFunctionName := functionName.Sanitize()
+
+	b := &strings.Builder{}
+	b.WriteString("create or replace function ")
+	b.WriteString(quotedFunctionName)
+	b.WriteString(`() returns trigger language plpgsql as $$
+declare
+	r record;
+begin
+	if tg_op = 'DELETE' then
+		r := old;
+	else
+		r := new;
+	end if;
+	perform pg_notify('`)
+	b.WriteString(strings.ReplaceAll(t.changesChannel(), "'", "''"))
+	b.WriteString(`', json_build_object('action', lower(tg_op), 'pk', json_build_array(`)
+	for i, idx := range t.pkIndexes {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("r.")
+		b.WriteString(t.Columns[idx].quotedName)
+		b.WriteString("::text")
+	}
+	b.WriteString(`))::text);
+	return null;
+end
+$$;
+create trigger pgxrecord_notify after insert or update or delete on `)
+	b.WriteString(t.quotedQualifiedName)
+	b.WriteString(" for each row execute function ")
+	b.WriteString(quotedFunctionName)
+	b.WriteString("();")
+
+	return b.String()
+}
+
+// decodeText decodes the text format value s as the type oid. If oid is not a known type s is returned unchanged.
+func decodeText(m *pgtype.Map, oid uint32, s string) (any, error) {
+	dt, ok := m.TypeForOID(oid)
+	if !ok {
+		return s, nil
+	}
+
+	return dt.Codec.DecodeValue(m, oid, pgtype.TextFormatCode, []byte(s))
+}
+
+// WatchChanges listens on conn for changes to the table and calls handler with the action (insert, update, or delete)
+// and the changed record. The record is found by primary key with FindByPK. For delete the row no longer exists so the
+// record only has its primary key attributes set. They are decoded with the column types so they have the same Go
+// types as the attributes of a found record. Changes to rows that no longer exist when the
+// notification is received are skipped. The trigger from ChangesTriggerSQL must be
+// installed separately.
+//
+// conn must be dedicated to WatchChanges as it blocks until ctx is canceled or an error occurs. It returns nil when ctx
+// is canceled. conn may be closed when ctx is canceled.
+func (t *Table) WatchChanges(ctx context.Context, conn *pgx.Conn, handler func(action string, record *Record)) error {
+	if !t.finalized {
+		t.MustFinalize()
+	}
+
+	if t.noPrimaryKey {
+		return fmt.Errorf("pgxrecord.Table (%s): WatchChanges: %w", t.quotedQualifiedName, errNoPrimaryKey)
+	}
+
+	channel := t.changesChannel()
+	_, err := conn.Exec(ctx, "listen "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("pgxrecord.Table (%s): WatchChanges: %w", t.quotedQualifiedName, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("pgxrecord.Table (%s): WatchChanges: %w", t.quotedQualifiedName, err)
+		}
+
+		if notification.Channel != channel {
+			continue
+		}
+
+		var change changeNotification
+		err = json.Unmarshal([]byte(notification.Payload), &change)
+		if err != nil {
+			return fmt.Errorf("pgxrecord.Table (%s): WatchChanges: invalid notification %q: %w", t.quotedQualifiedName, notification.Payload, err)
+		}
+
+		if len(change.PK) != len(t.pkIndexes) {
+			return fmt.Errorf("pgxrecord.Table (%s): WatchChanges: invalid notification %q: expected %d primary key values", t.quotedQualifiedName, notification.Payload, len(t.pkIndexes))
+		}
+
+		if change.Action == "delete" {
+			record := t.NewRecord()
+			for i, idx := range t.pkIndexes {
+				record.attributes[idx], err = decodeText(conn.TypeMap(), t.Columns[idx].OID, change.PK[i])
+				if err != nil {
+					return fmt.Errorf("pgxrecord.Table (%s): WatchChanges: invalid notification %q: %w", t.quotedQualifiedName, notification.Payload, err)
+				}
+			}
+			handler(change.Action, record)
+			continue
+		}
+
+		pk := make([]any, len(change.PK))
+		for i := range change.PK {
+			pk[i] = change.PK[i]
+		}
+
+		record, err := t.FindByPK(ctx, conn, pk...)
+		if err != nil {
+			if IsNotFound(err) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("pgxrecord.Table (%s): WatchChanges: %w", t.quotedQualifiedName, err)
+		}
+
+		handler(change.Action, record)
+	}
+}
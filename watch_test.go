@@ -0,0 +1,151 @@
+package pgxrecord_test
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgxrecord"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableChangesTriggerSQL(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"public", "widgets"},
+		Columns: []*pgxrecord.Column{
+			{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	sql := table.ChangesTriggerSQL()
+	require.Contains(t, sql, `create or replace function "public"."pgxrecord_notify_widgets"() returns trigger`)
+	require.Contains(t, sql, `perform pg_notify('pgxrecord_changes:public.widgets', json_build_object('action', lower(tg_op), 'pk', json_build_array(r."id"::text))::text);`)
+	require.Contains(t, sql, `create trigger pgxrecord_notify after insert or update or delete on "public"."widgets" for each row execute function "public"."pgxrecord_notify_widgets"();`)
+}
+
+func TestTableChangesTriggerSQLLongTableName(t *testing.T) {
+	t.Parallel()
+
+	newTable := func(name string) *pgxrecord.Table {
+		return &pgxrecord.Table{
+			Name: pgx.Identifier{"public", name},
+			Columns: []*pgxrecord.Column{
+				{Name: "id", OID: pgtype.Int4OID, NotNull: true, PrimaryKey: true},
+			},
+		}
+	}
+
+	longName := strings.Repeat("a", 60)
+	sql := newTable(longName + "_1").ChangesTriggerSQL()
+
+	channel := regexp.MustCompile(`pg_notify\('([^']*)'`).FindStringSubmatch(sql)
+	require.Len(t, channel, 2)
+	require.LessOrEqual(t, len(channel[1]), 63)
+
+	function := regexp.MustCompile(`create or replace function "public"\."([^"]*)"`).FindStringSubmatch(sql)
+	require.Len(t, function, 2)
+	require.LessOrEqual(t, len(function[1]), 63)
+
+	otherSQL := newTable(longName + "_2").ChangesTriggerSQL()
+	require.NotContains(t, otherSQL, channel[1])
+	require.NotContains(t, otherSQL, function[1])
+}
+
+func TestTableChangesTriggerSQLNoPrimaryKey(t *testing.T) {
+	t.Parallel()
+
+	table := &pgxrecord.Table{
+		Name: pgx.Identifier{"widgets"},
+		Columns: []*pgxrecord.Column{
+			{Name: "name", OID: pgtype.TextOID, NotNull: true, PrimaryKey: false},
+		},
+	}
+
+	require.Panics(t, func() { table.ChangesTriggerSQL() })
+}
+
+func TestTableWatchChanges(t *testing.T) {
+	t.Parallel()
+
+	defaultConnTestRunner.RunTest(context.Background(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		// The table must be visible to both connections so it cannot be temporary.
+		_, err := conn.Exec(ctx, `drop table if exists pgxrecord_watch_changes;
+create table pgxrecord_watch_changes (
+	id int primary key generated by default as identity,
+	name text not null
+)`)
+		require.NoError(t, err)
+		defer conn.Exec(context.Background(), `drop table pgxrecord_watch_changes; drop function pgxrecord_notify_pgxrecord_watch_changes()`)
+
+		table := &pgxrecord.Table{
+			Name: pgx.Identifier{"pgxrecord_watch_changes"},
+		}
+		err = table.LoadAllColumns(ctx, conn)
+		require.NoError(t, err)
+
+		_, err = conn.Exec(ctx, table.ChangesTriggerSQL())
+		require.NoError(t, err)
+
+		watchConn, err := pgx.Connect(ctx, os.Getenv("PGXRECORD_TEST_DATABASE"))
+		require.NoError(t, err)
+		defer watchConn.Close(context.Background())
+
+		watchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		type change struct {
+			action string
+			id     any
+			name   any
+		}
+		changes := make(chan change, 100)
+		watchErrChan := make(chan error, 1)
+		go func() {
+			watchErrChan <- table.WatchChanges(watchCtx, watchConn, func(action string, record *pgxrecord.Record) {
+				changes <- change{action: action, id: record.Get("id"), name: record.Get("name")}
+			})
+		}()
+
+		// The listen may not have started yet so insert until a change is received.
+		var received change
+	insertLoop:
+		for {
+			_, err = conn.Exec(ctx, `insert into pgxrecord_watch_changes (name) values ('John')`)
+			require.NoError(t, err)
+
+			select {
+			case received = <-changes:
+				break insertLoop
+			case <-time.After(50 * time.Millisecond):
+			case <-watchCtx.Done():
+				t.Fatal("timed out waiting for change")
+			}
+		}
+		require.Equal(t, "insert", received.action)
+		require.Equal(t, "John", received.name)
+		insertedID := received.id
+
+		_, err = conn.Exec(ctx, `delete from pgxrecord_watch_changes where id = $1`, insertedID)
+		require.NoError(t, err)
+
+		for {
+			received = <-changes
+			if received.action == "delete" {
+				break
+			}
+		}
+		require.Equal(t, insertedID, received.id)
+		require.Nil(t, received.name)
+
+		cancel()
+		require.NoError(t, <-watchErrChan)
+	})
+}